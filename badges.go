@@ -0,0 +1,85 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Badges maps the badge name to its version, it is used for the badges and the badge-info tag
+// https://dev.twitch.tv/docs/irc/tags#privmsg-twitch-tags
+type Badges map[string]string
+
+// ParseBadges parses "subscriber/12,bits/1000"
+func ParseBadges(tag []byte) Badges {
+	badges := Badges{}
+	if len(tag) == 0 {
+		return badges
+	}
+	for _, badge := range bytes.Split(tag, []byte{44}) { // ,
+		if i := bytes.IndexByte(badge, 47); i != -1 { // /
+			badges[string(badge[:i])] = string(badge[i+1:])
+		} else {
+			badges[string(badge)] = ""
+		}
+	}
+	return badges
+}
+
+// Badges of a PRIVMSG, USERSTATE, GLOBALUSERSTATE or USERNOTICE
+func (m IRCMessage) Badges() Badges {
+	return ParseBadges(m.Tags["badges"])
+}
+
+// BadgeInfo holds the exact subscriber months, the version in Badges is only the tier of the badge
+func (m IRCMessage) BadgeInfo() Badges {
+	return ParseBadges(m.Tags["badge-info"])
+}
+
+// SubscriberMonths from the badge-info tag, 0 if the user is not subscribed
+func (m IRCMessage) SubscriberMonths() int {
+	return m.BadgeInfo().SubscriberMonths()
+}
+
+func (b Badges) Has(name string) bool {
+	_, ok := b[name]
+	return ok
+}
+
+// Version returns "" if the badge is missing
+func (b Badges) Version(name string) string {
+	return b[name]
+}
+
+func (b Badges) IsBroadcaster() bool {
+	return b.Has("broadcaster")
+}
+
+func (b Badges) IsMod() bool {
+	return b.Has("moderator")
+}
+
+func (b Badges) IsVIP() bool {
+	return b.Has("vip")
+}
+
+// IsSubscriber includes founders, they have a founder badge instead of the subscriber badge
+func (b Badges) IsSubscriber() bool {
+	return b.Has("subscriber") || b.Has("founder")
+}
+
+// IsStaff includes Twitch admins
+func (b Badges) IsStaff() bool {
+	return b.Has("staff") || b.Has("admin")
+}
+
+// SubscriberMonths has to be called on the badge-info, e.g. PrivateMessage.BadgeInfo
+func (b Badges) SubscriberMonths() int {
+	months, ok := b["subscriber"]
+	if !ok {
+		months = b["founder"]
+	}
+	i, _ := strconv.Atoi(months)
+	return i
+}
@@ -0,0 +1,47 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"testing"
+)
+
+func TestParseBadges(t *testing.T) {
+	badges := ParseBadges([]byte("broadcaster/1,subscriber/3012,bits/1000,premium"))
+
+	if !badges.Has("premium") || badges.Version("premium") != "" {
+		t.Errorf("premium = %v", badges)
+	}
+	if badges.Version("bits") != "1000" || badges.Version("moderator") != "" {
+		t.Errorf("version = %v", badges)
+	}
+	if !badges.IsBroadcaster() || !badges.IsSubscriber() || badges.IsMod() || badges.IsVIP() || badges.IsStaff() {
+		t.Errorf("roles = %v", badges)
+	}
+	if len(ParseBadges(nil)) != 0 {
+		t.Error("empty badges tag")
+	}
+}
+
+func TestSubscriberMonths(t *testing.T) {
+	tests := []struct {
+		line   string
+		months int
+	}{
+		{`@badge-info=subscriber/14;badges=subscriber/12,bits/100;mod=0 :tmi.twitch.tv USERSTATE #spddl`, 14},
+		{`@badge-info=founder/7;badges=founder/0 :spddl!spddl@spddl.tmi.twitch.tv PRIVMSG #spddl :hi`, 7},
+		{`@badge-info=;badges=;color=#1E90FF;display-name=spddl;user-id=29218758;user-type= :tmi.twitch.tv GLOBALUSERSTATE`, 0},
+		{`@badge-info=subscriber/1;badges=subscriber/0;login=foo;msg-id=resub :tmi.twitch.tv USERNOTICE #spddl`, 1},
+	}
+	for _, tt := range tests {
+		msg := mustParse(t, tt.line)
+		if got := msg.SubscriberMonths(); got != tt.months {
+			t.Errorf("%s: months = %d, want %d", tt.line, got, tt.months)
+		}
+	}
+
+	pm := ParsePrivateMessage(mustParse(t, tests[1].line))
+	if !pm.Badges.IsSubscriber() || pm.BadgeInfo.SubscriberMonths() != 7 {
+		t.Errorf("private message badges = %v badge-info = %v", pm.Badges, pm.BadgeInfo)
+	}
+}
@@ -0,0 +1,131 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"sort"
+	"strings"
+)
+
+// The desired channels are Client.Channel, changed by Join and Part. The joined
+// channels are the ones tmi confirmed on the current connection with our own JOIN
+// echo or a ROOMSTATE, lines of a retired connection confirm nothing. After every
+// (re)connect only the missing channels are joined.
+
+// JoinedChannels returns the channels confirmed on the current connection
+func (c *Client) JoinedChannels() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	joined := c.joined[c.conn]
+	channels := make([]string, 0, len(joined))
+	for channel := range joined {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// missingChannels are desired but not joined
+func (c *Client) missingChannels() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var missing []string
+	for _, channel := range c.Channel {
+		if !c.joined[c.conn][strings.ToLower(channel)] {
+			missing = append(missing, channel)
+		}
+	}
+	return missing
+}
+
+// resetJoined is called for every new connection, it hasn't joined anything yet
+// and nothing written to the old one is answered
+func (c *Client) resetJoined() {
+	c.mu.Lock()
+	c.joined = map[Transport]map[string]bool{c.conn: {}}
+	c.echoes = nil
+	c.mu.Unlock()
+}
+
+// rejoin joins the missing channels after RPL_WELCOME, a full join queue must not block the parser
+func (c *Client) rejoin() {
+	if missing := c.missingChannels(); len(missing) != 0 {
+		c.joinCommand(missing, QueueError)
+	}
+}
+
+// membershipOn tracks our own JOIN, PART and the ROOMSTATE and USERSTATE tmi sends after a JOIN on conn
+func (c *Client) membershipOn(conn Transport, msg IRCMessage) {
+	if len(msg.Params) == 0 {
+		return
+	}
+	channel := strings.ToLower(strings.TrimPrefix(string(msg.Params[0]), "#"))
+	own := strings.EqualFold(msg.Source().Nick, c.User)
+
+	switch string(msg.Command) {
+	case "JOIN":
+		if own {
+			c.confirmJoin(conn, channel)
+			c.joinEchoed(conn, channel)
+		}
+	case "ROOMSTATE":
+		c.confirmJoin(conn, channel)
+		c.mergeRoomState(channel, msg)
+	case "PART":
+		if own {
+			c.mu.Lock()
+			delete(c.joined[conn], channel)
+			delete(c.leaving, channel)
+			delete(c.moderator, channel)
+			delete(c.badges, channel)
+			delete(c.rooms, channel)
+			delete(c.slowUntil, channel)
+			c.mu.Unlock()
+		}
+	case "USERSTATE": // after a JOIN and every message we send
+		badges := msg.Badges()
+		mod := string(msg.Tags["mod"]) == "1" || badges.IsMod() || badges.IsBroadcaster()
+		c.mu.Lock()
+		if c.moderator == nil {
+			c.moderator = map[string]bool{}
+			c.badges = map[string]Badges{}
+		}
+		c.moderator[channel] = mod
+		c.badges[channel] = badges
+		c.mu.Unlock()
+	}
+}
+
+// Moderator is true if the last USERSTATE of channel (without #) had mod=1 or the broadcaster badge,
+// Say then uses the limit for channels with Moderator or Operator status
+func (c *Client) Moderator(channel string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.moderator[strings.ToLower(strings.TrimPrefix(channel, "#"))]
+}
+
+// confirmJoin marks channel as joined on conn, if conn is the current one or the new one of a handover
+func (c *Client) confirmJoin(conn Transport, channel string) {
+	c.mu.Lock()
+	joined, ok := c.joined[conn]
+	if !ok || c.leaving[channel] {
+		c.mu.Unlock()
+		return
+	}
+	confirmed := joined[channel] // by the JOIN echo or the ROOMSTATE before
+	joined[channel] = true
+	var joinEcho chan string
+	if !confirmed && conn == c.handoverConn && conn != c.conn {
+		joinEcho = c.joinEcho
+	}
+	c.mu.Unlock()
+
+	if joinEcho != nil { // a handover waits for the channels, each is sent once
+		select {
+		case joinEcho <- channel:
+		default:
+		}
+	}
+}
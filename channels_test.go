@@ -0,0 +1,193 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMembership(t *testing.T) {
+	c := &Client{User: "JustinFan1234", Channel: []string{"a", "B", "c"}}
+	c.resetJoined()
+
+	for _, line := range []string{
+		":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #a",
+		":foo!foo@foo.tmi.twitch.tv JOIN #c", // not our JOIN
+		"@slow=0 :tmi.twitch.tv ROOMSTATE #b",
+		":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #x",
+		":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv PART #x",
+	} {
+		c.membershipOn(c.getConn(), mustParse(t, line))
+	}
+
+	if got, want := c.JoinedChannels(), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("JoinedChannels() = %v, want %v", got, want)
+	}
+	if got, want := c.missingChannels(), []string{"c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missingChannels() = %v, want %v", got, want)
+	}
+
+	c.resetJoined()
+	if got := c.missingChannels(); len(got) != 3 {
+		t.Errorf("missingChannels() after a new connection = %v", got)
+	}
+}
+
+func TestMembershipRetiredConnection(t *testing.T) {
+	current, retired := &WebsocketTransport{}, &WebsocketTransport{}
+	c := &Client{User: "justinfan1234", Channel: []string{"a"}, conn: current}
+	c.resetJoined()
+
+	c.membershipOn(retired, mustParse(t, ":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #a"))
+	c.membershipOn(retired, mustParse(t, "@slow=0 :tmi.twitch.tv ROOMSTATE #a"))
+	if got := c.JoinedChannels(); len(got) != 0 {
+		t.Errorf("JoinedChannels() = %v after lines of a retired connection", got)
+	}
+
+	c.membershipOn(current, mustParse(t, "@slow=0 :tmi.twitch.tv ROOMSTATE #a"))
+	if got, want := c.JoinedChannels(), []string{"a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("JoinedChannels() = %v, want %v", got, want)
+	}
+}
+
+func TestConfirmJoinOnce(t *testing.T) {
+	current, next := &WebsocketTransport{}, &WebsocketTransport{}
+	c := &Client{User: "justinfan1234", Channel: []string{"a", "b"}, conn: current, handoverConn: next}
+	c.resetJoined()
+	c.joined[next] = map[string]bool{}
+	c.joinEcho = make(chan string, len(c.Channel))
+
+	for _, line := range []string{
+		":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #a",
+		"@slow=0 :tmi.twitch.tv ROOMSTATE #a", // a again, must not take the place of b
+		":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #b",
+	} {
+		c.membershipOn(next, mustParse(t, line))
+	}
+	close(c.joinEcho)
+
+	var echoed []string
+	for channel := range c.joinEcho {
+		echoed = append(echoed, channel)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(echoed, want) {
+		t.Errorf("joinEcho = %v, want %v", echoed, want)
+	}
+}
+
+func TestJoinPending(t *testing.T) {
+	c := newQueueClient(QueueBlock)
+	c.User = "justinfan1234"
+	c.resetJoined()
+
+	c.Join([]string{"a"})
+	c.rejoin() // RPL_WELCOME before the queued JOIN was sent
+	if got, want := drain(c.emitQueue.Join), []string{":justinfan1234! JOIN #a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("join queue = %v, want %v", got, want)
+	}
+	c.joinSent(":justinfan1234! JOIN #a")
+
+	c.membershipOn(c.getConn(), mustParse(t, ":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #a"))
+	c.Part([]string{"a"})
+	c.membershipOn(c.getConn(), mustParse(t, "@slow=0 :tmi.twitch.tv ROOMSTATE #a")) // still in flight
+	c.Join([]string{"a"})                                                            // before the PART echo
+	if got, want := drain(c.emitQueue.Join), []string{":justinfan1234! PART #a", ":justinfan1234! JOIN #a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("join queue = %v, want %v", got, want)
+	}
+}
+
+func TestRejoinKeepsOnConnect(t *testing.T) {
+	var connections int32
+	joins := make(chan string, 10)
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		n := atomic.AddInt32(&connections, 1)
+		if _, err := conn.readUntil("NICK"); err != nil {
+			return
+		}
+		_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+		line, err := conn.readUntil("JOIN")
+		if err != nil {
+			return
+		}
+		joins <- line
+		_ = conn.writeLine(":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #spddl")
+		if n == 1 {
+			return // drop the first session
+		}
+		for {
+			if _, err := conn.readLine(); err != nil {
+				return
+			}
+		}
+	})
+
+	c, err := NewClient(&Client{Server: tmi.URL, User: "justinfan1234", Channel: []string{"spddl"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.ReconnectPolicy = ConstantBackoff{Delay: 10 * time.Millisecond}
+
+	var connects int32
+	c.OnConnect = func(status bool) {
+		atomic.AddInt32(&connects, 1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case line := <-joins:
+			if line != ":justinfan1234! JOIN #spddl" {
+				t.Errorf("line = %q", line)
+			}
+		case <-ctx.Done():
+			t.Fatalf("JOIN %d was not sent", i+1)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if n := atomic.LoadInt32(&connects); n != 2 {
+		t.Errorf("OnConnect was called %d times, want 2", n)
+	}
+	if got := c.JoinedChannels(); !reflect.DeepEqual(got, []string{"spddl"}) {
+		t.Errorf("JoinedChannels() = %v", got)
+	}
+}
+
+func TestModerator(t *testing.T) {
+	c := newQueueClient(QueueBlock)
+	c.User = "justinfan1234"
+
+	for _, line := range []string{
+		"@badges=;mod=1 :tmi.twitch.tv USERSTATE #modded",
+		"@badges=broadcaster/1;mod=0 :tmi.twitch.tv USERSTATE #justinfan1234",
+		"@badges=moderator/1;mod=1 :tmi.twitch.tv USERSTATE #demoted",
+		"@badges=;mod=0 :tmi.twitch.tv USERSTATE #demoted", // the last USERSTATE counts
+		"@badges=;mod=1 :tmi.twitch.tv USERSTATE #parted",
+		":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv PART #parted",
+	} {
+		c.membershipOn(c.getConn(), mustParse(t, line))
+	}
+
+	for channel, want := range map[string]bool{"modded": true, "#Modded": true, "justinfan1234": true, "demoted": false, "parted": false, "unknown": false} {
+		if got := c.Moderator(channel); got != want {
+			t.Errorf("Moderator(%q) = %v", channel, got)
+		}
+	}
+
+	c.Say("modded", "hi", false)
+	c.Say("demoted", "hi", false)
+	c.Say("demoted", "hi", true) // override
+	if stats := c.QueueStats(); stats[queueChatModOp].Depth != 2 || stats[queueChat].Depth != 1 {
+		t.Errorf("chat %+v, chatModOp %+v", stats[queueChat], stats[queueChatModOp])
+	}
+}
@@ -3,23 +3,42 @@
 package twitch
 
 import (
-	"fmt"
+	"context"
+	"log"
 	"strings"
+	"unicode/utf8"
 )
 
+// ircLine builds an outbound line with IRCMessage.MarshalIRC, so user input can't inject line breaks
+func ircLine(prefix, command string, params ...string) (string, error) {
+	msg := IRCMessage{Prefix: []byte(prefix), Command: []byte(command)}
+	for _, param := range params {
+		msg.Params = append(msg.Params, []byte(param))
+	}
+	line, err := msg.MarshalIRC()
+	return string(line), err
+}
+
 func (c *Client) login() {
+	for _, line := range c.loginLines() {
+		c.push(queueAuthenticate, line, QueueBlock)
+	}
+}
+
+func (c *Client) loginLines() []string {
 	// Membership: Adds membership state event data. By default, we do not send this data to clients without this capability. https://dev.twitch.tv/docs/irc/membership
 	// Tags: Adds IRC V3 message tags to several commands, if enabled with the commands capability. https://dev.twitch.tv/docs/irc/tags
 	// Commands: Enables several Twitch-specific commands. https://dev.twitch.tv/docs/irc/commands
-	c.emitQueue.Authenticate <- "CAP REQ :twitch.tv/tags twitch.tv/commands"
+	lines := []string{"CAP REQ :twitch.tv/tags twitch.tv/commands"}
 	if !strings.HasPrefix(c.User, "justinfan") {
-		c.emitQueue.Authenticate <- fmt.Sprintf("PASS oauth:%s", c.Oauth)
+		lines = append(lines, "PASS oauth:"+c.Oauth)
 	}
-	c.emitQueue.Authenticate <- fmt.Sprintf("NICK %s", c.User)
+	return append(lines, "NICK "+c.User)
 }
 
-// Join accept channels only in lowercase
-func (c *Client) Join(channels []string) {
+// Join accept channels only in lowercase, they are joined again after every reconnect.
+// A full join queue is handled by QueuePolicy, a channel whose JOIN is dropped is joined after the next reconnect
+func (c *Client) Join(channels []string) error {
 	for _, channel := range channels {
 		_, exist := c.channelExists(channel)
 		if !exist {
@@ -28,14 +47,61 @@ func (c *Client) Join(channels []string) {
 			c.mu.Unlock()
 		}
 	}
-	c.joinCommand(channels)
+
+	c.mu.Lock()
+	var missing []string
+	for _, channel := range channels {
+		channel := strings.ToLower(channel)
+		if !c.joined[c.conn][channel] || c.leaving[channel] { // a Part before its echo still needs the JOIN
+			missing = append(missing, channel)
+		}
+		delete(c.leaving, channel)
+	}
+	c.mu.Unlock()
+	return c.joinCommand(missing, c.QueuePolicy)
 }
 
-func (c *Client) joinCommand(channels []string) {
+// joinCommand queues a JOIN for every channel that isn't already waiting in the join queue,
+// it returns the first error
+func (c *Client) joinCommand(channels []string, policy QueuePolicy) error {
+	var first error
 	for _, channel := range channels {
-		c.emitQueue.Join <- fmt.Sprintf(":%s! JOIN #%s", c.User, channel)
+		line, err := ircLine(c.User+"!", "JOIN", "#"+channel)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		c.mu.Lock()
+		pending := c.joinPending[line]
+		if !pending {
+			if c.joinPending == nil {
+				c.joinPending = map[string]bool{}
+			}
+			c.joinPending[line] = true
+		}
+		c.mu.Unlock()
+		if pending {
+			continue
+		}
+
+		if err := c.push(queueJoin, line, policy); err != nil {
+			log.Println(line+":", err)
+			c.joinSent(line)
+			if first == nil {
+				first = err
+			}
+		}
 	}
 	// https://github.com/gempir/go-twitch-irc/issues/102#issuecomment-510882229
+	return first
+}
+
+// joinSent removes a JOIN line from the pending ones
+func (c *Client) joinSent(line string) {
+	c.mu.Lock()
+	delete(c.joinPending, line)
+	c.mu.Unlock()
 }
 
 // Part accept channels only in lowercase
@@ -51,12 +117,30 @@ func (c *Client) Part(channels []string) {
 			c.mu.Unlock()
 		}
 	}
+
+	c.mu.Lock()
+	if c.leaving == nil {
+		c.leaving = map[string]bool{}
+	}
+	for _, channel := range channels {
+		channel := strings.ToLower(channel)
+		c.leaving[channel] = true
+		if line, err := ircLine(c.User+"!", "JOIN", "#"+channel); err == nil {
+			delete(c.joinPending, line) // a Join after this Part queues its JOIN again
+		}
+	}
+	c.mu.Unlock()
 	c.partCommand(channels)
 }
 
 func (c *Client) partCommand(channels []string) {
 	for _, channel := range channels {
-		c.emitQueue.Join <- fmt.Sprintf(":%s! PART #%s", c.User, channel)
+		line, err := ircLine(c.User+"!", "PART", "#"+channel)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		c.push(queueJoin, line, QueueBlock)
 	}
 }
 
@@ -71,26 +155,86 @@ func (c *Client) channelExists(channel string) (int, bool) {
 	return -1, false
 }
 
-const sayTemplate = ":tmi.twitch.tv PRIVMSG #%s :%s"
+// Say channel without #, a full queue is handled by QueuePolicy.
+// Channels where the client is Moderator use the higher limit on their own, modPrivileged forces it.
+// A message that waits for slow mode is queued later, a room mode that forbids it returns a *SendError (see RoomState).
+// With SplitLongMessages a message over MaxMessageLength is sent in pieces (see SplitMessage),
+// a message identical to the last one within DuplicateWindow is handled by DuplicateStrategy.
+func (c *Client) Say(channel, msg string, modPrivileged bool) error {
+	return c.say(channel, msg, modPrivileged, c.QueuePolicy)
+}
+
+// TrySay doesn't wait, it returns ErrQueueFull if the queue is full and a *SendError for
+// ErrSlowMode or ErrDuplicate (with DuplicateDelay) if the message can't be queued yet.
+func (c *Client) TrySay(channel, msg string, modPrivileged bool) error {
+	return c.say(channel, msg, modPrivileged, QueueError)
+}
+
+func (c *Client) say(channel, msg string, modPrivileged bool, policy QueuePolicy) error {
+	channel = strings.ToLower(channel)
+	pieces := []string{msg}
+	if c.SplitLongMessages && utf8.RuneCountInString(msg) > MaxMessageLength {
+		pieces = SplitMessage(msg, MaxMessageLength, c.ContinuationMarker)
+	}
+
+	// every piece is checked before the first one is queued
+	if err := c.checkRoom(channel); err != nil {
+		return err
+	}
+	for i, piece := range pieces {
+		if _, err := ircLine("tmi.twitch.tv", "PRIVMSG", "#"+channel, piece); err != nil {
+			return err
+		}
+		if _, err := c.bypassDuplicate(channel, piece); err != nil {
+			return err
+		}
+		if i > 0 && piece == pieces[i-1] && c.DuplicateStrategy == DuplicateDrop {
+			return duplicateError(channel)
+		}
+	}
 
-// Say channel without #
-func (c *Client) Say(channel, msg string, modPrivileged bool) {
-	if modPrivileged {
-		c.emitQueue.ModOp <- fmt.Sprintf(sayTemplate, channel, msg)
-	} else {
-		c.emitQueue.RateLimit <- fmt.Sprintf(sayTemplate, channel, msg)
+	queue := queueChat
+	if modPrivileged || c.Moderator(channel) {
+		queue = queueChatModOp
+	}
+	for i, piece := range pieces { // one after the other, slow mode keeps them in order
+		if err := c.sayPiece(channel, queue, piece, policy); err != nil {
+			if len(pieces) > 1 {
+				return &SplitError{Sent: i, Pieces: len(pieces), Err: err}
+			}
+			return err
+		}
 	}
+	return nil
 }
 
-const whisperTemplate = ":tmi.twitch.tv PRIVMSG #jtv :/w %s %s"
+// sayPiece queues one PRIVMSG after DuplicateStrategy and slow mode
+func (c *Client) sayPiece(channel, queue, msg string, policy QueuePolicy) error {
+	msg, err := c.bypassDuplicate(channel, msg)
+	if err != nil {
+		return err
+	}
+	line, err := ircLine("tmi.twitch.tv", "PRIVMSG", "#"+channel, msg)
+	if err != nil {
+		return err
+	}
+	return c.pushRoom(context.Background(), channel, queue, line, msg, policy)
+}
 
-func (c *Client) Whisper(nick, msg string) {
-	switch {
-	case c.BotVerified:
-		c.emitQueue.WhisperVerifiedBots <- fmt.Sprintf(whisperTemplate, nick, msg)
-	case c.BotKnown:
-		c.emitQueue.WhisperKnownBot <- fmt.Sprintf(whisperTemplate, nick, msg)
-	default:
-		c.emitQueue.Whisper <- fmt.Sprintf(whisperTemplate, nick, msg)
+// Whisper returns ErrWhisperRecipients once the account whispered too many different accounts today
+func (c *Client) Whisper(nick, msg string) error {
+	line, err := ircLine("tmi.twitch.tv", "PRIVMSG", "#jtv", "/w "+nick+" "+msg)
+	if err != nil {
+		return err
 	}
+	return c.whisperRecipient(nick, func() error {
+		switch {
+		case c.BotVerified:
+			return c.push(queueWhisperVerifiedBots, line, c.QueuePolicy)
+		case c.BotKnown:
+			return c.push(queueWhisperKnownBot, line, c.QueuePolicy)
+		default:
+			return c.push(queueWhisper, line, c.QueuePolicy)
+		}
+	})
 }
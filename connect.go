@@ -0,0 +1,161 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// AuthError is returned by Connect if tmi rejects PASS/NICK with a NOTICE
+type AuthError struct {
+	Notice string // "Login authentication failed" or "Improperly formatted auth"
+}
+
+func (e *AuthError) Error() string {
+	return "twitch: " + e.Notice
+}
+
+// DialError is returned by Connect if the Transport could not be dialed
+type DialError struct {
+	Server string
+	Err    error
+}
+
+func (e *DialError) Error() string {
+	return fmt.Sprintf("twitch: dial %s: %v", e.Server, e.Err)
+}
+
+func (e *DialError) Unwrap() error {
+	return e.Err
+}
+
+// Connect dials Server, authenticates and blocks until tmi welcomes the client (RPL_WELCOME).
+// It returns a *DialError, an *AuthError or the error of ctx. After a successful
+// Connect the client reconnects on its own like it does after Run.
+func (c *Client) Connect(ctx context.Context) error {
+	welcome := make(chan error, 1)
+	c.mu.Lock()
+	c.welcome = welcome
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.welcome = nil
+		c.mu.Unlock()
+	}()
+
+	c.setState(Dialing)
+	if err := c.dial(ctx); err != nil {
+		c.setState(Disconnected)
+		return &DialError{Server: c.Server, Err: err}
+	}
+	c.login()
+
+	select {
+	case err := <-welcome:
+		if err != nil {
+			c.dropConn()
+			return err
+		}
+		return nil
+
+	case <-ctx.Done():
+		c.dropConn()
+		return ctx.Err()
+	}
+}
+
+// dial opens a new Transport, the client is Authenticating afterwards
+func (c *Client) dial(ctx context.Context) error {
+	conn, err := c.openConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+	c.resetJoined()
+	c.setState(Authenticating)
+	go c.read(conn)
+	return nil
+}
+
+// openConn dials a new Transport without using it yet
+func (c *Client) openConn(ctx context.Context) (Transport, error) {
+	conn := c.newTransport()
+	if err := conn.Dial(ctx, c.Server, c.DialOptions); err != nil {
+		return nil, err
+	}
+
+	if c.Debug {
+		log.Printf("Connection was successfully established with %s\n", c.Server)
+	}
+	return conn, nil
+}
+
+// dropConn closes the connection without reconnecting
+func (c *Client) dropConn() {
+	c.mu.Lock()
+	c.reconnect = false
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.mu.Unlock()
+	c.setState(Disconnected)
+}
+
+// handoverOf is true for the new connection of a handover until it replaces the current one
+func (c *Client) handoverOf(conn Transport) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return conn != nil && conn == c.handoverConn && conn != c.conn
+}
+
+// loginResult reports the outcome of the login on conn to a waiting Connect or handover
+func (c *Client) loginResult(conn Transport, err error) {
+	handover := c.handoverOf(conn)
+	c.mu.Lock()
+	welcome := c.welcome
+	if handover {
+		welcome = c.handoverWelcome
+	} else if welcome != nil && err == nil {
+		c.reconnect = true // before the read loop sees the connection drop
+	}
+	c.mu.Unlock()
+
+	if welcome != nil {
+		select {
+		case welcome <- err:
+		default:
+		}
+	}
+}
+
+// authNotice checks for the NOTICEs tmi sends instead of RPL_WELCOME
+func (c *Client) authNotice(conn Transport, msg IRCMessage) {
+	if len(msg.Params) < 2 {
+		return
+	}
+
+	switch notice := string(msg.Params[1]); notice {
+	case "Login authentication failed", "Improperly formatted auth", "Login unsuccessful":
+		err := &AuthError{Notice: notice}
+		c.mu.RLock()
+		waiting := c.welcome != nil
+		c.mu.RUnlock()
+
+		if waiting || c.handoverOf(conn) {
+			c.loginResult(conn, err)
+			return
+		}
+
+		// a reconnect was rejected, the ReconnectPolicy decides if and when to try again
+		log.Println(err)
+		c.mu.Lock()
+		c.authFailures++
+		c.mu.Unlock()
+		c.reconnectAfter(err)
+	}
+}
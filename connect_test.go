@@ -0,0 +1,145 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, server string) *Client {
+	t.Helper()
+	c, err := NewClient(&Client{Server: server, User: "justinfan1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestConnect(t *testing.T) {
+	tmi := newFakeTMI(t, welcome)
+	c := newTestClient(t, tmi.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsConnected() {
+		t.Error("not connected after Connect")
+	}
+}
+
+func TestConnectAuthFailed(t *testing.T) {
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		if _, err := conn.readUntil("NICK"); err != nil {
+			return
+		}
+		_ = conn.writeLine(":tmi.twitch.tv NOTICE * :Login authentication failed")
+		_, _ = conn.readLine()
+	})
+	c := newTestClient(t, tmi.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := c.Connect(ctx)
+
+	var authErr *AuthError
+	if !errors.As(err, &authErr) || authErr.Notice != "Login authentication failed" {
+		t.Fatalf("Connect() = %v, want *AuthError", err)
+	}
+	if c.IsConnected() {
+		t.Error("still connected after a failed login")
+	}
+}
+
+func TestReconnectAuthFailed(t *testing.T) {
+	var connections int32
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		switch atomic.AddInt32(&connections, 1) {
+		case 1: // drop the first session right after the login
+			if _, err := conn.readUntil("NICK"); err == nil {
+				_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+			}
+		case 2:
+			if _, err := conn.readUntil("NICK"); err == nil {
+				_ = conn.writeLine(":tmi.twitch.tv NOTICE * :Login authentication failed")
+			}
+			_, _ = conn.readLine()
+		default:
+			welcome(conn)
+		}
+	})
+	c := newTestClient(t, tmi.URL)
+	c.ReconnectPolicy = ConstantBackoff{Delay: 10 * time.Millisecond}
+
+	var mu sync.Mutex
+	var attempts []int
+	c.OnReconnecting = func(attempt int, delay time.Duration, err error) {
+		mu.Lock()
+		attempts = append(attempts, attempt)
+		mu.Unlock()
+	}
+	reconnected := make(chan struct{}, 1)
+	c.OnReconnected = func() {
+		reconnected <- struct{}{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-reconnected:
+	case <-ctx.Done():
+		t.Fatal("a rejected login during a reconnect stopped the client")
+	}
+	if n := atomic.LoadInt32(&connections); n != 3 {
+		t.Errorf("%d connections, want 3", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts) != 2 || attempts[1] != 1 {
+		t.Errorf("attempts = %v, want [1 1]", attempts)
+	}
+}
+
+func TestConnectDialError(t *testing.T) {
+	tmi := newFakeTMI(t, welcome)
+	server := tmi.URL
+	tmi.Close()
+	c := newTestClient(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := c.Connect(ctx)
+
+	var dialErr *DialError
+	if !errors.As(err, &dialErr) || dialErr.Server != server {
+		t.Fatalf("Connect() = %v, want *DialError", err)
+	}
+}
+
+func TestConnectTimeout(t *testing.T) {
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		for { // never welcomes
+			if _, err := conn.readLine(); err != nil {
+				return
+			}
+		}
+	})
+	c := newTestClient(t, tmi.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := c.Connect(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Connect() = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,31 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+)
+
+// DialOptions are passed to the Transport, nil dials like websocket.Dial without options.
+// In the browser (js/wasm) the websocket is dialed by the browser and the options are ignored.
+type DialOptions struct {
+	HTTPHeader  http.Header                           // extra headers of the websocket handshake
+	Proxy       func(*http.Request) (*url.URL, error) // e.g. http.ProxyFromEnvironment or http.ProxyURL, websocket only
+	TLSConfig   *tls.Config                           // custom CA or client certificates, wss:// and ircs://
+	Compression Compression                           // permessage-deflate of the websocket
+
+	// HTTPClient replaces Proxy and TLSConfig for the websocket handshake,
+	// its Transport must support HTTP/1.1 upgrades
+	HTTPClient *http.Client
+}
+
+// Compression mode of the websocket (permessage-deflate)
+type Compression int
+
+const (
+	CompressionDefault         Compression = iota // offered without context takeover
+	CompressionDisabled                           // not offered
+	CompressionContextTakeover                    // better ratio, about 8 kB per connection more
+)
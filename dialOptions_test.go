@@ -0,0 +1,102 @@
+// +build windows linux
+
+package twitch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDialOptionsTLS(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	tmi := newFakeTMITLS(t, func(conn *tmiConn) {
+		headers <- conn.header
+		welcome(conn)
+	})
+
+	c := newTestClient(t, tmi.URL)
+	c.DialOptions = &DialOptions{
+		HTTPHeader:  http.Header{"X-Bot": []string{"spddl"}},
+		TLSConfig:   tmi.Client().Transport.(*http.Transport).TLSClientConfig, // trusts the test certificate
+		Compression: CompressionContextTakeover,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	header := <-headers
+	if header.Get("X-Bot") != "spddl" {
+		t.Errorf("X-Bot = %q", header.Get("X-Bot"))
+	}
+	if ext := header.Get("Sec-WebSocket-Extensions"); ext != "permessage-deflate" {
+		t.Errorf("Sec-WebSocket-Extensions = %q", ext)
+	}
+}
+
+func TestDialOptionsUnknownCA(t *testing.T) {
+	tmi := newFakeTMITLS(t, welcome)
+	c := newTestClient(t, tmi.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var dialErr *DialError
+	if err := c.Connect(ctx); !errors.As(err, &dialErr) {
+		t.Fatalf("Connect = %v, want a *DialError", err)
+	}
+}
+
+func TestDialOptionsProxy(t *testing.T) {
+	tmi := newFakeTMITLS(t, welcome)
+
+	var tunnels int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer target.Close()
+		atomic.AddInt32(&tunnels, 1)
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go io.Copy(target, conn)
+		_, _ = io.Copy(conn, target)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	c := newTestClient(t, tmi.URL)
+	c.DialOptions = &DialOptions{
+		Proxy:     http.ProxyURL(proxyURL),
+		TLSConfig: tmi.Client().Transport.(*http.Transport).TLSClientConfig,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&tunnels); n != 1 {
+		t.Errorf("%d tunnels, want 1", n)
+	}
+}
@@ -0,0 +1,96 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DuplicateStrategy decides what happens to a message that is identical to the last one
+// sent to the channel within DuplicateWindow, tmi would drop it with msg_duplicate
+type DuplicateStrategy int
+
+const (
+	DuplicateSend   DuplicateStrategy = iota // send it anyway
+	DuplicateSuffix                          // append DuplicateSuffixText, the next identical message is sent without it again
+	DuplicateDelay                           // queue it once the window expired
+	DuplicateDrop                            // return a *SendError for msg_duplicate
+)
+
+// DuplicateWindow of the identical message rule
+const DuplicateWindow = 30 * time.Second
+
+// DuplicateSuffixText is invisible in chat: a space and the tag space U+E0000
+const DuplicateSuffixText = " \U000E0000"
+
+type sentMessage struct {
+	text string
+	at   time.Time
+}
+
+// bypassDuplicate applies DuplicateStrategy to msg and returns the text to send. The message before
+// it is the last one of the outbox of channel, else the last one queued. DuplicateDelay is left to
+// pushRoom, the window may still move until that message is written.
+func (c *Client) bypassDuplicate(channel, msg string) (string, error) {
+	if c.DuplicateStrategy == DuplicateSend || c.delaysDuplicate(msg) {
+		return msg, nil
+	}
+
+	now := c.limiter().clock.Now()
+	c.mu.RLock()
+	last, ok := c.lastMessage[channel]
+	if ob, waiting := c.outboxes[channel]; waiting && len(ob.lines) != 0 {
+		last, ok = sentMessage{text: ob.lines[len(ob.lines)-1].text, at: now}, true
+	}
+	c.mu.RUnlock()
+	if !ok || last.text != msg || now.Sub(last.at) >= DuplicateWindow {
+		return msg, nil
+	}
+
+	if c.DuplicateStrategy == DuplicateSuffix {
+		return msg + DuplicateSuffixText, nil
+	}
+	return "", duplicateError(channel)
+}
+
+// delaysDuplicate is true if a duplicate of msg waits for the window to expire
+func (c *Client) delaysDuplicate(msg string) bool {
+	switch c.DuplicateStrategy {
+	case DuplicateDelay:
+		return true
+	case DuplicateSuffix:
+		return utf8.RuneCountInString(msg+DuplicateSuffixText) > MaxMessageLength // no room for the suffix
+	}
+	return false
+}
+
+func duplicateError(channel string) error {
+	return &SendError{Channel: channel, MsgID: "msg_duplicate", Notice: "Your message was not sent because it is identical to the previous one you sent, less than 30 seconds ago."}
+}
+
+// rememberMessage is the last message of channel, queued now, messageWritten moves its time to the real send
+func (c *Client) rememberMessage(channel, msg string) {
+	at := c.limiter().clock.Now()
+	c.mu.Lock()
+	if c.lastMessage == nil {
+		c.lastMessage = map[string]sentMessage{}
+	}
+	c.lastMessage[strings.ToLower(channel)] = sentMessage{text: msg, at: at}
+	c.mu.Unlock()
+}
+
+// messageWritten starts the window of the last message at the time its PRIVMSG was written
+func (c *Client) messageWritten(channel, text string) {
+	if c.DuplicateStrategy == DuplicateSend {
+		return
+	}
+	at := c.limiter().clock.Now()
+
+	c.mu.Lock()
+	if last, ok := c.lastMessage[channel]; ok && last.text == text {
+		c.lastMessage[channel] = sentMessage{text: text, at: at}
+	}
+	c.mu.Unlock()
+}
@@ -0,0 +1,114 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSizedQueueClient is newQueueClient with queues of size
+func newSizedQueueClient(policy QueuePolicy, size int) *Client {
+	c := newQueueClient(policy)
+	c.QueueSize = size
+	c.newQueues()
+	return c
+}
+
+func TestDuplicateStrategy(t *testing.T) {
+	for _, tt := range []struct {
+		strategy DuplicateStrategy
+		queued   []string // after three "hi" within the window
+		err      error
+	}{
+		{DuplicateSend, []string{"hi", "hi", "hi"}, nil},
+		{DuplicateSuffix, []string{"hi", "hi" + DuplicateSuffixText, "hi"}, nil},
+		{DuplicateDrop, []string{"hi"}, ErrDuplicate},
+	} {
+		clock := &fakeClock{now: time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)}
+		c := newSizedQueueClient(QueueBlock, 10)
+		c.Limiter = NewAccountLimiterClock(UserLimits(), clock)
+		c.DuplicateStrategy = tt.strategy
+
+		var err error
+		for i := 0; i < 3; i++ {
+			if e := c.Say("spddl", "hi", false); e != nil {
+				err = e
+			}
+		}
+		if !errors.Is(err, tt.err) || (err != nil && tt.err == nil) {
+			t.Errorf("strategy %d: Say = %v", tt.strategy, err)
+		}
+
+		var queued []string
+		for _, line := range drain(c.emitQueue.RateLimit) {
+			queued = append(queued, strings.TrimPrefix(line, ":tmi.twitch.tv PRIVMSG #spddl :"))
+		}
+		if strings.Join(queued, "|") != strings.Join(tt.queued, "|") {
+			t.Errorf("strategy %d: queued %q, want %q", tt.strategy, queued, tt.queued)
+		}
+	}
+}
+
+func TestDuplicateDelay(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := newSizedQueueClient(QueueBlock, 10)
+	c.Limiter = NewAccountLimiterClock(UserLimits(), clock)
+	c.DuplicateStrategy = DuplicateDelay
+
+	c.Say("spddl", "hi", false)
+	c.privmsgDone(nil, <-c.emitQueue.RateLimit, nil)
+	clock.Advance(10 * time.Second)
+	if err := c.Say("spddl", "hi", false); err != nil { // waits 20s in the outbox, not in Say
+		t.Fatal(err)
+	}
+	if err := c.TrySay("spddl", "hi", false); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("TrySay = %v, want ErrDuplicate", err)
+	}
+	c.Say("other", "hi", false) // another channel
+	waitForTimer(t, clock)
+
+	if depth := len(c.emitQueue.RateLimit); depth != 1 {
+		t.Fatalf("depth %d", depth)
+	}
+	<-c.emitQueue.RateLimit
+	clock.Advance(20 * time.Second)
+	select {
+	case line := <-c.emitQueue.RateLimit:
+		if line != ":tmi.twitch.tv PRIVMSG #spddl :hi" {
+			t.Errorf("queued %q", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the duplicate was not queued after the window")
+	}
+}
+
+func TestDuplicateWindowStartsAtSend(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := newQueueClient(QueueBlock)
+	c.Limiter = NewAccountLimiterClock(UserLimits(), clock)
+	c.DuplicateStrategy = DuplicateDelay
+
+	c.Say("spddl", "hi", false)
+	first := <-c.emitQueue.RateLimit
+	clock.Advance(5 * time.Second)
+	c.Say("spddl", "hi", false)
+
+	clock.Advance(15 * time.Second) // the rate limiter held the first one
+	c.privmsgDone(nil, first, nil)
+	waitForTimer(t, clock) // checked again, 30s from now
+
+	clock.Advance(29 * time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if depth := len(c.emitQueue.RateLimit); depth != 0 {
+		t.Fatalf("queued 29s after the first one was written")
+	}
+	clock.Advance(time.Second)
+	select {
+	case <-c.emitQueue.RateLimit:
+	case <-time.After(time.Second):
+		t.Fatal("the duplicate was not queued 30s after the first one was written")
+	}
+}
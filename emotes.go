@@ -0,0 +1,169 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Emote is a single occurrence of an emote in a message, Start and End are code point offsets (inclusive)
+type Emote struct {
+	ID    string
+	Name  string
+	Start int
+	End   int
+}
+
+type FragmentType int
+
+const (
+	FragmentText FragmentType = iota
+	FragmentEmote
+	FragmentMention
+	FragmentURL
+)
+
+// Fragment is a part of a message, Start and End are code point offsets (inclusive)
+type Fragment struct {
+	Type  FragmentType
+	Text  string
+	Start int
+	End   int
+	Emote *Emote // only set for FragmentEmote
+}
+
+// Fragments splits the message text into text, emote, mention and URL fragments
+func (pm PrivateMessage) Fragments() []Fragment {
+	return ParseFragments(pm.IRCMessage.Tags["emotes"], pm.Text)
+}
+
+// ParseFragments splits text with the ranges of the emotes tag ("25:0-4,12-16/1902:6-10").
+// The ranges count code points, not bytes, so text is walked as runes.
+func ParseFragments(emotesTag []byte, text string) []Fragment {
+	runes := []rune(text)
+	var fragments []Fragment
+
+	position := 0
+	for _, emote := range parseEmotesTag(emotesTag, text) {
+		if emote.Start < position || emote.End >= len(runes) {
+			continue // overlapping or out of range
+		}
+
+		fragments = appendTextFragments(fragments, runes[position:emote.Start], position)
+
+		e := emote
+		fragments = append(fragments, Fragment{
+			Type:  FragmentEmote,
+			Text:  e.Name,
+			Start: e.Start,
+			End:   e.End,
+			Emote: &e,
+		})
+		position = emote.End + 1
+	}
+
+	return appendTextFragments(fragments, runes[position:], position)
+}
+
+// appendTextFragments detects mentions and URLs in runes, offset is the code point offset of runes[0]
+func appendTextFragments(fragments []Fragment, runes []rune, offset int) []Fragment {
+	start := 0
+	for start < len(runes) {
+		end := start
+		for end < len(runes) && runes[end] != 32 { // space
+			end++
+		}
+		word := string(runes[start:end])
+
+		switch {
+		case hasURLPrefix(word):
+			fragments = append(fragments, Fragment{Type: FragmentURL, Text: word, Start: offset + start, End: offset + end - 1})
+			start = end
+
+		case runes[start] == 64: // @
+			name := start + 1
+			for name < end && isLoginRune(runes[name]) {
+				name++
+			}
+			if name > start+1 {
+				fragments = append(fragments, Fragment{Type: FragmentMention, Text: string(runes[start:name]), Start: offset + start, End: offset + name - 1})
+				start = name
+			}
+		}
+
+		// the rest of the word and the following space are plain text
+		for end < len(runes) && runes[end] == 32 {
+			end++
+		}
+		if start < end {
+			fragments = appendText(fragments, string(runes[start:end]), offset+start, offset+end-1)
+		}
+		start = end
+	}
+	return fragments
+}
+
+// appendText merges text into a preceding text fragment
+func appendText(fragments []Fragment, text string, start, end int) []Fragment {
+	if last := len(fragments) - 1; last >= 0 && fragments[last].Type == FragmentText {
+		fragments[last].Text += text
+		fragments[last].End = end
+		return fragments
+	}
+	return append(fragments, Fragment{Type: FragmentText, Text: text, Start: start, End: end})
+}
+
+func hasURLPrefix(word string) bool {
+	word = strings.ToLower(word)
+	return (strings.HasPrefix(word, "http://") && len(word) > 7) || (strings.HasPrefix(word, "https://") && len(word) > 8)
+}
+
+func isLoginRune(r rune) bool {
+	return r == 95 || (r >= 48 && r <= 57) || (r >= 65 && r <= 90) || (r >= 97 && r <= 122) // _ 0-9 A-Z a-z
+}
+
+// parseEmotesTag parses "25:0-4,12-16/1902:6-10" into emotes ordered by position
+func parseEmotesTag(value []byte, text string) []Emote {
+	if len(value) == 0 {
+		return nil
+	}
+
+	runes := []rune(text)
+	var emotes []Emote
+	for _, emote := range bytes.Split(value, []byte{47}) { // /
+		colon := bytes.IndexByte(emote, 58) // :
+		if colon == -1 {
+			continue
+		}
+		id := string(emote[:colon])
+
+		for _, position := range bytes.Split(emote[colon+1:], []byte{44}) { // ,
+			dash := bytes.IndexByte(position, 45) // -
+			if dash == -1 {
+				continue
+			}
+			start, err := strconv.Atoi(string(position[:dash]))
+			if err != nil {
+				continue
+			}
+			end, err := strconv.Atoi(string(position[dash+1:]))
+			if err != nil || start < 0 || end < start {
+				continue
+			}
+
+			e := Emote{ID: id, Start: start, End: end}
+			if end < len(runes) {
+				e.Name = string(runes[start : end+1])
+			}
+			emotes = append(emotes, e)
+		}
+	}
+
+	sort.Slice(emotes, func(i, j int) bool {
+		return emotes[i].Start < emotes[j].Start
+	})
+	return emotes
+}
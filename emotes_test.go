@@ -0,0 +1,76 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFragments(t *testing.T) {
+	tests := []struct {
+		emotes string
+		text   string
+		want   []Fragment
+	}{
+		{
+			``, `hello world`,
+			[]Fragment{{Type: FragmentText, Text: "hello world", Start: 0, End: 10}},
+		},
+		{
+			`25:0-4,12-16/1902:6-10`, `Kappa Keepo Kappa`,
+			[]Fragment{
+				{Type: FragmentEmote, Text: "Kappa", Start: 0, End: 4, Emote: &Emote{ID: "25", Name: "Kappa", Start: 0, End: 4}},
+				{Type: FragmentText, Text: " ", Start: 5, End: 5},
+				{Type: FragmentEmote, Text: "Keepo", Start: 6, End: 10, Emote: &Emote{ID: "1902", Name: "Keepo", Start: 6, End: 10}},
+				{Type: FragmentText, Text: " ", Start: 11, End: 11},
+				{Type: FragmentEmote, Text: "Kappa", Start: 12, End: 16, Emote: &Emote{ID: "25", Name: "Kappa", Start: 12, End: 16}},
+			},
+		},
+		{ // 😂 is a surrogate pair in UTF-16 and 4 bytes in UTF-8, but one code point
+			`25:2-6`, `😂 Kappa ä`,
+			[]Fragment{
+				{Type: FragmentText, Text: "😂 ", Start: 0, End: 1},
+				{Type: FragmentEmote, Text: "Kappa", Start: 2, End: 6, Emote: &Emote{ID: "25", Name: "Kappa", Start: 2, End: 6}},
+				{Type: FragmentText, Text: " ä", Start: 7, End: 8},
+			},
+		},
+		{
+			`555555560:35-36`, `dafür fährt er noch zu gut @Dracon :D`,
+			[]Fragment{
+				{Type: FragmentText, Text: "dafür fährt er noch zu gut ", Start: 0, End: 26},
+				{Type: FragmentMention, Text: "@Dracon", Start: 27, End: 33},
+				{Type: FragmentText, Text: " ", Start: 34, End: 34},
+				{Type: FragmentEmote, Text: ":D", Start: 35, End: 36, Emote: &Emote{ID: "555555560", Name: ":D", Start: 35, End: 36}},
+			},
+		},
+		{
+			``, `@spddl, see https://dev.twitch.tv/docs @`,
+			[]Fragment{
+				{Type: FragmentMention, Text: "@spddl", Start: 0, End: 5},
+				{Type: FragmentText, Text: ", see ", Start: 6, End: 11},
+				{Type: FragmentURL, Text: "https://dev.twitch.tv/docs", Start: 12, End: 37},
+				{Type: FragmentText, Text: " @", Start: 38, End: 39},
+			},
+		},
+		{ // out of range emotes are kept as text
+			`25:4-99`, `abc Kappa`,
+			[]Fragment{{Type: FragmentText, Text: "abc Kappa", Start: 0, End: 8}},
+		},
+	}
+	for _, tt := range tests {
+		got := ParseFragments([]byte(tt.emotes), tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseFragments(%q, %q)\n got %+v\nwant %+v", tt.emotes, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPrivateMessageFragments(t *testing.T) {
+	pm := ParsePrivateMessage(mustParse(t, "@emotes=25:0-4 :spddl!spddl@spddl.tmi.twitch.tv PRIVMSG #spddl :\x01ACTION Kappa 🎉\x01"))
+
+	fragments := pm.Fragments()
+	if len(fragments) != 2 || fragments[0].Type != FragmentEmote || fragments[1].Text != " 🎉" {
+		t.Errorf("fragments = %+v", fragments)
+	}
+}
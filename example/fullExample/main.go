@@ -34,31 +34,7 @@ func main() {
 		channel := msg.Params[0][1:] // to remove # from Channel Parameter
 		msgline := msg.Params[1]
 
-		// 		log.Printf(`{
-		// 	"Raw": "%s",
-		// 	"Tags": {
-		// 		"badge-info": "%s",
-		// 		"badges": "%s",
-		// 		"color": "%s",
-		// 		"display-name": "%s",
-		// 		"emotes": "%s",
-		// 		"flags": "%s",
-		// 		"id": "%s",
-		// 		"mod": "%s",
-		// 		"room-id": "%s",
-		// 		"subscriber": "%s",
-		// 		"tmi-sent-ts": "%s",
-		// 		"turbo": "%s",
-		// 		"user-id": "%s",
-		// 		"user-type": "%s"
-		// 	},
-		// 	"Command": "%s",
-		// 	"Params": [
-		// 		"%s",
-		// 		"%s"
-		// 	],
-		// 	"Prefix": "%s"
-		// }`, msg.Raw, msg.Tags["badge-info"], msg.Tags["badges"], msg.Tags["color"], msg.Tags["display-name"], msg.Tags["emotes"], msg.Tags["flags"], msg.Tags["id"], msg.Tags["mod"], msg.Tags["room-id"], msg.Tags["subscriber"], msg.Tags["tmi-sent-ts"], msg.Tags["turbo"], msg.Tags["user-id"], msg.Tags["user-type"], msg.Command, msg.Params[0], msg.Params[1], msg.Prefix)
+		// twitch.NewJSONLWriter(os.Stdout).WriteIRCMessage(msg) // as JSON Lines
 
 		if bytes.Equal(channel, []byte("#spddl")) {
 			if bytes.Contains(msgline, []byte("hi")) {
@@ -98,69 +74,14 @@ func main() {
 	}
 
 	bot.OnJoinMessage = func(msg twitch.IRCMessage) {
-		log.Printf("join channel: %s", msg.Params[0][1:]) // to remove # from Channel Parameter
+		log.Printf("%s join channel: %s", msg.Source().Nick, msg.Params[0][1:]) // to remove # from Channel Parameter
 	}
 
 	bot.OnPartMessage = func(msg twitch.IRCMessage) {
-		log.Printf("leave channel: %s", msg.Params[0][1:]) // to remove # from Channel Parameter
+		log.Printf("%s leave channel: %s", msg.Source().Nick, msg.Params[0][1:]) // to remove # from Channel Parameter
 	}
 
 	bot.OnUserNoticeMessage = func(msg twitch.IRCMessage) { // https://github.com/tmijs/tmi.js/blob/4bb66c433b8ae28326b4cd8567357e6ea729e91a/lib/client.js#L668
-		msg.Tags["system-msg"] = bytes.ReplaceAll(msg.Tags["system-msg"], []byte{92, 115}, []byte{32}) // "\\s", " "
-		msg.Tags["msg-param-sub-plan-name"] = bytes.ReplaceAll(msg.Tags["msg-param-sub-plan-name"], []byte{92, 115}, []byte{32})
-		msg.Tags["msg-param-sub-plan-name"] = bytes.ReplaceAll(msg.Tags["msg-param-sub-plan-name"], []byte{92, 115}, []byte{32})
-
-		// msgID := msg.Tags["message-type"]
-		// switch string(msgID) { // https://github.com/tmijs/tmi.js/blob/4bb66c433b8ae28326b4cd8567357e6ea729e91a/lib/client.js#L680
-		// // Handle resub
-		// case "resub":
-
-		// // Handle sub
-		// case "sub":
-
-		// // Handle gift sub
-		// case "subgift":
-
-		// // Handle anonymous gift sub
-		// // Need proof that this event occur
-		// case "anonsubgift":
-
-		// // Handle random gift subs
-		// case "submysterygift":
-
-		// // Handle anonymous random gift subs
-		// // Need proof that this event occur
-		// case "anonsubmysterygift":
-
-		// // Handle user upgrading from Prime to a normal tier sub
-		// case "primepaidupgrade":
-
-		// // Handle user upgrading from a gifted sub
-		// case "giftpaidupgrade":
-
-		// // Handle user upgrading from an anonymous gifted sub
-		// case "anongiftpaidupgrade":
-
-		// // Handle raid
-		// case "raid":
-		// 	var username []byte
-		// 	if len(msg.Tags["msg-param-displayName"]) != 0 {
-		// 		username = msg.Tags["msg-param-displayName"]
-		// 	} else {
-		// 		username = msg.Tags["msg-param-login"]
-		// 	}
-		// 	viewers := msg.Tags["msg-param-viewerCount"]
-
-		// 	_ = username
-		// 	_ = viewers
-
-		// // Handle ritual
-		// case "ritual":
-
-		// default:
-
-		// }
-
 		var stats []string
 		for key, value := range msg.Tags {
 			stats = append(stats, fmt.Sprintf("%s: %s", key, value))
@@ -168,6 +89,22 @@ func main() {
 		log.Printf("> USERNOTICE, %s: %s\n", msg.Params[0][1:], strings.Join(stats, ", "))
 	}
 
+	bot.OnSubEvent = func(event twitch.SubEvent) {
+		log.Printf("> SUB, %s: %s (%s)\n", event.Channel, event.DisplayName, event.SubPlan)
+	}
+
+	bot.OnResubEvent = func(event twitch.ResubEvent) {
+		log.Printf("> RESUB, %s: %s %d months\n", event.Channel, event.DisplayName, event.CumulativeMonths)
+	}
+
+	bot.OnSubGiftEvent = func(event twitch.SubGiftEvent) {
+		log.Printf("> SUBGIFT, %s: %s => %s\n", event.Channel, event.DisplayName, event.RecipientDisplayName)
+	}
+
+	bot.OnRaidEvent = func(event twitch.RaidEvent) {
+		log.Printf("> RAID, %s: %s with %d viewers\n", event.Channel, event.RaiderDisplayName, event.ViewerCount)
+	}
+
 	bot.OnClearChatMessage = func(msg twitch.IRCMessage) {
 		channel := msg.Params[0][1:] // to remove # from Channel Parameter
 		targetUser := msg.Params[1]
@@ -2,9 +2,11 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"log"
 	"os"
 	"os/signal"
+	"time"
 
 	"github.com/spddl/go-twitch-ws"
 )
@@ -40,7 +42,12 @@ func main() {
 		log.Printf("%s - %s: %s", msg.Params[0][1:], msg.Tags["display-name"], msg.Params[1])
 	}
 
-	bot.Run()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	err = bot.Connect(ctx) // or bot.Run() without waiting for the login
+	cancel()
+	if err != nil {
+		panic(err)
+	}
 
 	for { // ctrl - c
 		<-interrupt
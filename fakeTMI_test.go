@@ -0,0 +1,89 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nhooyr.io/websocket"
+)
+
+// fakeTMI is a websocket server that speaks just enough of tmi for the client tests
+type fakeTMI struct {
+	*httptest.Server
+	URL string // ws:// address
+}
+
+type tmiConn struct {
+	ctx    context.Context
+	conn   *websocket.Conn
+	header http.Header // of the handshake
+}
+
+func newFakeTMI(t *testing.T, handle func(conn *tmiConn)) *fakeTMI {
+	t.Helper()
+	s := httptest.NewServer(tmiHandler(handle))
+	t.Cleanup(s.Close)
+	return &fakeTMI{Server: s, URL: "ws" + strings.TrimPrefix(s.URL, "http")}
+}
+
+// newFakeTMITLS listens on wss://, the certificate is only trusted by s.Client()
+func newFakeTMITLS(t *testing.T, handle func(conn *tmiConn)) *fakeTMI {
+	t.Helper()
+	s := httptest.NewTLSServer(tmiHandler(handle))
+	t.Cleanup(s.Close)
+	return &fakeTMI{Server: s, URL: "wss" + strings.TrimPrefix(s.URL, "https")}
+}
+
+func tmiHandler(handle func(conn *tmiConn)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+		handle(&tmiConn{ctx: r.Context(), conn: conn, header: r.Header})
+	})
+}
+
+// readLine returns the next line of the client without "\r\n"
+func (c *tmiConn) readLine() (string, error) {
+	_, data, err := c.conn.Read(c.ctx)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func (c *tmiConn) writeLine(line string) error {
+	return c.conn.Write(c.ctx, websocket.MessageText, []byte(line+"\r\n"))
+}
+
+// readUntil reads lines up to the first line containing s
+func (c *tmiConn) readUntil(s string) (string, error) {
+	for {
+		line, err := c.readLine()
+		if err != nil || strings.Contains(line, s) {
+			return line, err
+		}
+	}
+}
+
+// welcome answers NICK with RPL_WELCOME and keeps reading
+func welcome(conn *tmiConn) {
+	if _, err := conn.readUntil("NICK"); err != nil {
+		return
+	}
+	if err := conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!"); err != nil {
+		return
+	}
+	for {
+		if _, err := conn.readLine(); err != nil {
+			return
+		}
+	}
+}
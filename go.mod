@@ -1,5 +1,5 @@
 module github.com/spddl/go-twitch-ws
 
-go 1.15
+go 1.16
 
 require nhooyr.io/websocket v1.8.7
@@ -0,0 +1,219 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// how long the old connection is kept open while the new one joins the channels
+const handoverJoinTimeout = 15 * time.Second
+
+// how long ids are compared after the old connection is closed, for messages still in flight
+const handoverDedupeGrace = 5 * time.Second
+
+// handover answers RECONNECT (https://dev.twitch.tv/docs/irc/commands#reconnect-twitch-commands):
+// a new connection is opened, logged in and joined to Channel before the old one is closed.
+// Until then the old connection stays the current one and the client stays Ready, the new
+// connection is written to directly. Messages with an id tag that arrive on both connections
+// are only dispatched once.
+func (c *Client) handover() {
+	c.mu.Lock()
+	if c.handingOver {
+		c.mu.Unlock()
+		return
+	}
+	c.handingOver = true
+	old := c.conn
+	welcome := make(chan error, 1)
+	c.handoverWelcome = welcome
+	c.joinEcho = make(chan string, len(c.Channel))
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.handingOver = false
+		if c.handoverConn != c.conn { // failed, its confirmations are gone with it
+			delete(c.joined, c.handoverConn)
+			c.forgetEchoes(c.handoverConn)
+		}
+		c.handoverConn = nil
+		c.handoverWelcome = nil
+		c.joinEcho = nil
+		dedupe := c.dedupe
+		c.mu.Unlock()
+
+		time.AfterFunc(handoverDedupeGrace, func() {
+			c.mu.Lock()
+			if c.dedupe == dedupe { // not a newer handover
+				c.dedupe = nil
+			}
+			c.mu.Unlock()
+		})
+	}()
+
+	if c.Debug {
+		log.Println("RECONNECT: opening a new connection")
+	}
+
+	// if the new connection fails the old one stays, it reconnects as usual once tmi closes it
+	conn, err := c.openConn(c.context)
+	if err != nil {
+		log.Println("RECONNECT:", err)
+		return
+	}
+	c.mu.Lock()
+	c.handoverConn = conn
+	if c.joined == nil {
+		c.joined = map[Transport]map[string]bool{}
+	}
+	c.joined[conn] = map[string]bool{}
+	c.mu.Unlock()
+	go c.read(conn)
+
+	if err = c.handoverLogin(conn, welcome); err != nil {
+		log.Println("RECONNECT:", err)
+		conn.Close()
+		return
+	}
+
+	c.mu.RLock()
+	channels := append([]string(nil), c.Channel...)
+	joinEcho := c.joinEcho
+	c.mu.RUnlock()
+
+	l := c.limiter()
+	pending := make(map[string]bool, len(channels))
+	for _, channel := range channels {
+		line, err := ircLine(c.User+"!", "JOIN", "#"+channel)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if !l.wait(c.context, joinRateQueueLimitTemplate, &l.join) {
+			return
+		}
+		if err := c.writeTo(conn, []byte(line)); err != nil {
+			log.Println("RECONNECT:", err)
+			conn.Close()
+			return
+		}
+		pending[strings.ToLower(channel)] = true
+	}
+
+	timeout := time.After(handoverJoinTimeout)
+	for len(pending) != 0 {
+		select {
+		case channel := <-joinEcho:
+			delete(pending, channel)
+		case <-timeout:
+			log.Printf("RECONNECT: %d channels were not joined on the new connection\n", len(pending))
+			pending = nil
+		case <-c.context.Done():
+			return
+		}
+	}
+
+	c.mu.Lock()
+	swap := c.conn == old // not replaced by a reconnect in the meantime
+	if swap {
+		c.conn = conn
+		delete(c.joined, old)
+		c.forgetEchoes(old)
+	}
+	c.mu.Unlock()
+	if !swap {
+		conn.Close()
+		return
+	}
+
+	if old != nil {
+		old.Close()
+	}
+	if c.Debug {
+		log.Println("RECONNECT: old connection closed")
+	}
+	c.rejoin() // channels that timed out and JOINs of a Join that went to the old connection
+}
+
+// startDedupe is called by the read loop on RECONNECT, the lines after it may arrive again
+// on the new connection before handover runs
+func (c *Client) startDedupe() {
+	c.mu.Lock()
+	if c.dedupe == nil || !c.handingOver {
+		c.dedupe = newMessageIDs(1024)
+	}
+	c.mu.Unlock()
+}
+
+// handoverLogin sends CAP/PASS/NICK to conn and waits for its RPL_WELCOME
+func (c *Client) handoverLogin(conn Transport, welcome <-chan error) error {
+	l := c.limiter()
+	for _, line := range c.loginLines() {
+		if !l.wait(c.context, authenticateRateQueueLimitTemplate, &l.authenticate) {
+			return c.context.Err()
+		}
+		if err := c.writeTo(conn, []byte(line)); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case err := <-welcome:
+		return err
+	case <-time.After(handoverJoinTimeout):
+		return errors.New("no RPL_WELCOME on the new connection")
+	case <-c.context.Done():
+		return c.context.Err()
+	}
+}
+
+// duplicate is true for messages that were already dispatched by the other connection of a handover
+func (c *Client) duplicate(msg IRCMessage) bool {
+	c.mu.RLock()
+	dedupe := c.dedupe
+	c.mu.RUnlock()
+
+	id, ok := msg.Tags["id"]
+	if dedupe == nil || !ok || len(id) == 0 {
+		return false
+	}
+	return dedupe.seenBefore(string(id))
+}
+
+// messageIDs remembers the last size ids
+type messageIDs struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+	ring []string
+	next int
+}
+
+func newMessageIDs(size int) *messageIDs {
+	return &messageIDs{
+		seen: make(map[string]struct{}, size),
+		ring: make([]string, size),
+	}
+}
+
+// seenBefore adds id and reports whether it was already known
+func (m *messageIDs) seenBefore(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.seen[id]; ok {
+		return true
+	}
+
+	if old := m.ring[m.next]; old != "" {
+		delete(m.seen, old)
+	}
+	m.ring[m.next] = id
+	m.next = (m.next + 1) % len(m.ring)
+	m.seen[id] = struct{}{}
+	return false
+}
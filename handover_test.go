@@ -0,0 +1,307 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMessageIDs(t *testing.T) {
+	ids := newMessageIDs(2)
+	for i, tt := range []struct {
+		id   string
+		seen bool
+	}{{"a", false}, {"a", true}, {"b", false}, {"c", false}, {"a", false}, {"c", true}} {
+		if got := ids.seenBefore(tt.id); got != tt.seen {
+			t.Errorf("%d: seenBefore(%q) = %v", i, tt.id, got)
+		}
+	}
+}
+
+func TestHandover(t *testing.T) {
+	var connections int32
+	oldClosed := make(chan struct{})
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		n := atomic.AddInt32(&connections, 1)
+		if _, err := conn.readUntil("NICK"); err != nil {
+			return
+		}
+		_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+		if _, err := conn.readUntil("JOIN"); err != nil {
+			return
+		}
+		_ = conn.writeLine(":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #spddl")
+
+		switch n {
+		case 1:
+			_ = conn.writeLine("@id=1 :foo!foo@foo.tmi.twitch.tv PRIVMSG #spddl :one")
+			_ = conn.writeLine(":tmi.twitch.tv RECONNECT")
+			_ = conn.writeLine("@id=2 :foo!foo@foo.tmi.twitch.tv PRIVMSG #spddl :two")
+			for { // until the client retires this connection
+				if _, err := conn.readLine(); err != nil {
+					close(oldClosed)
+					return
+				}
+			}
+		default:
+			_ = conn.writeLine("@id=2 :foo!foo@foo.tmi.twitch.tv PRIVMSG #spddl :two")
+			_ = conn.writeLine("@id=3 :foo!foo@foo.tmi.twitch.tv PRIVMSG #spddl :three")
+			for {
+				if _, err := conn.readLine(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	c, err := NewClient(&Client{Server: tmi.URL, User: "justinfan1234", Channel: []string{"spddl"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var mu sync.Mutex
+	var ids []string
+	c.OnPrivMsg = func(msg PrivateMessage) {
+		mu.Lock()
+		ids = append(ids, msg.ID)
+		mu.Unlock()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-oldClosed:
+	case <-ctx.Done():
+		t.Fatal("the old connection was not closed")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if !c.IsConnected() {
+		t.Error("not connected after the handover")
+	}
+	if n := atomic.LoadInt32(&connections); n != 2 {
+		t.Errorf("%d connections, want 2", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	seen := map[string]int{}
+	for _, id := range ids {
+		seen[id]++
+	}
+	if len(ids) != 3 || seen["1"] != 1 || seen["2"] != 1 || seen["3"] != 1 {
+		t.Errorf("ids = %v, want 1, 2 and 3 once", ids)
+	}
+}
+
+func TestHandoverLoginFailed(t *testing.T) {
+	var connections int32
+	rejected := make(chan struct{})
+	said := make(chan string, 1)
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		if _, err := conn.readUntil("NICK"); err != nil {
+			return
+		}
+		if atomic.AddInt32(&connections, 1) != 1 {
+			_ = conn.writeLine(":tmi.twitch.tv NOTICE * :Login authentication failed")
+			close(rejected)
+			return
+		}
+		_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+		_ = conn.writeLine(":tmi.twitch.tv RECONNECT")
+		if line, err := conn.readUntil("PRIVMSG"); err == nil {
+			said <- line
+		}
+		_, _ = conn.readLine()
+	})
+	c := newTestClient(t, tmi.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-rejected:
+	case <-ctx.Done():
+		t.Fatal("no handover")
+	}
+	if err := c.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Say("spddl", "still here", false); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case line := <-said:
+		if !strings.HasSuffix(line, "PRIVMSG #spddl :still here") {
+			t.Errorf("old connection got %q", line)
+		}
+	case <-ctx.Done():
+		t.Fatal("the old connection was not kept after a failed handover")
+	}
+}
+
+func TestHandoverStaysReady(t *testing.T) {
+	var connections int32
+	loggingIn := make(chan struct{})
+	said := make(chan struct{})
+	oldClosed := make(chan struct{})
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		if _, err := conn.readUntil("NICK"); err != nil {
+			return
+		}
+		if atomic.AddInt32(&connections, 1) != 1 {
+			close(loggingIn)
+			<-said // the old connection is still used while this one logs in
+			_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+			if _, err := conn.readUntil("JOIN"); err != nil {
+				return
+			}
+			_ = conn.writeLine(":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #spddl")
+			_, _ = conn.readLine()
+			return
+		}
+		_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+		for {
+			line, err := conn.readLine()
+			if err != nil {
+				close(oldClosed)
+				return
+			}
+			switch {
+			case strings.Contains(line, "JOIN"):
+				_ = conn.writeLine(":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #spddl")
+				_ = conn.writeLine(":tmi.twitch.tv RECONNECT")
+			case strings.Contains(line, "PRIVMSG"):
+				close(said)
+			}
+		}
+	})
+	c, err := NewClient(&Client{Server: tmi.URL, User: "justinfan1234", Channel: []string{"spddl"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var changes []State
+	c.OnStateChange = func(old, state State) {
+		mu.Lock()
+		changes = append(changes, state)
+		mu.Unlock()
+	}
+	select {
+	case <-loggingIn:
+	case <-ctx.Done():
+		t.Fatal("no handover")
+	}
+	if err := c.Say("spddl", "during the handover", false); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-oldClosed:
+	case <-ctx.Done():
+		t.Fatal("the old connection was not closed")
+	}
+	if !c.IsConnected() {
+		t.Error("not connected after the handover")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(changes) != 0 {
+		t.Errorf("state changes %v during a handover", changes)
+	}
+}
+
+func TestHandoverRejoin(t *testing.T) {
+	var connections int32
+	joining := make(chan struct{})
+	joinedOld := make(chan struct{})
+	joins := make(chan string, 4)
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		if _, err := conn.readUntil("NICK"); err != nil {
+			return
+		}
+		_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+		if atomic.AddInt32(&connections, 1) != 1 {
+			line, err := conn.readUntil("JOIN")
+			if err != nil {
+				return
+			}
+			joins <- line
+			close(joining)
+			<-joinedOld // a Join during the handover goes to the old connection
+			_ = conn.writeLine(":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #spddl")
+			for {
+				line, err := conn.readUntil("JOIN")
+				if err != nil {
+					return
+				}
+				joins <- line
+			}
+		}
+		for {
+			line, err := conn.readLine()
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasSuffix(line, "JOIN #spddl"):
+				_ = conn.writeLine(":justinfan1234!justinfan1234@justinfan1234.tmi.twitch.tv JOIN #spddl")
+				_ = conn.writeLine(":tmi.twitch.tv RECONNECT")
+			case strings.HasSuffix(line, "JOIN #late"):
+				close(joinedOld)
+			}
+		}
+	})
+	c, err := NewClient(&Client{Server: tmi.URL, User: "justinfan1234", Channel: []string{"spddl"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-joining:
+	case <-ctx.Done():
+		t.Fatal("no handover")
+	}
+	if err := c.Join([]string{"late"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"JOIN #spddl", "JOIN #late"} {
+		select {
+		case line := <-joins:
+			if !strings.HasSuffix(line, want) {
+				t.Errorf("new connection got %q, want %q", line, want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("no %s on the new connection", want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"unicode/utf8"
+)
+
+// ircMessageJSON is IRCMessage with strings, []byte would be encoded as base64.
+// Only a Raw line that isn't valid UTF-8 is also kept in RawBase64, the string would replace its bytes.
+type ircMessageJSON struct {
+	Raw       string
+	RawBase64 []byte `json:",omitempty"`
+	Tags      map[string]string
+	Command   string
+	Params    []string
+	Prefix    string
+}
+
+func (m IRCMessage) MarshalJSON() ([]byte, error) {
+	j := ircMessageJSON{
+		Raw:     string(m.Raw),
+		Tags:    make(map[string]string, len(m.Tags)),
+		Command: string(m.Command),
+		Params:  make([]string, len(m.Params)),
+		Prefix:  string(m.Prefix),
+	}
+	if !utf8.Valid(m.Raw) {
+		j.RawBase64 = m.Raw
+	}
+	for key, value := range m.Tags {
+		j.Tags[key] = string(value)
+	}
+	for i, param := range m.Params {
+		j.Params[i] = string(param)
+	}
+	return json.Marshal(j)
+}
+
+func (m *IRCMessage) UnmarshalJSON(data []byte) error {
+	var j ircMessageJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*m = IRCMessage{
+		Raw:     []byte(j.Raw),
+		Tags:    make(map[string][]byte, len(j.Tags)),
+		Command: []byte(j.Command),
+		Params:  make([][]byte, len(j.Params)),
+		Prefix:  []byte(j.Prefix),
+	}
+	if j.RawBase64 != nil {
+		m.Raw = j.RawBase64
+	}
+	for key, value := range j.Tags {
+		m.Tags[key] = []byte(value)
+	}
+	for i, param := range j.Params {
+		m.Params[i] = []byte(param)
+	}
+	return nil
+}
+
+// JSONLWriter writes one JSON document per line, it is safe for concurrent use
+//
+//	w := twitch.NewJSONLWriter(file)
+//	bot.OnPrivateMessage = w.WriteIRCMessage
+type JSONLWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONLWriter{enc: enc}
+}
+
+// Write encodes v (IRCMessage, PrivateMessage, SubEvent, ...) as a single line
+func (w *JSONLWriter) Write(v interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.enc.Encode(v)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return err
+}
+
+// WriteIRCMessage can be used as handler, e.g. for OnPrivateMessage, errors are kept for Err
+func (w *JSONLWriter) WriteIRCMessage(msg IRCMessage) {
+	_ = w.Write(msg)
+}
+
+// WritePrivateMessage can be used as OnPrivMsg handler, errors are kept for Err
+func (w *JSONLWriter) WritePrivateMessage(msg PrivateMessage) {
+	_ = w.Write(msg)
+}
+
+// Err returns the first write error
+func (w *JSONLWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
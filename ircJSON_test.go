@@ -0,0 +1,115 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestIRCMessageJSON(t *testing.T) {
+	msg := mustParse(t, `@badge-info=;badges=;color=#1E90FF;display-name=spddl;emotes=;id=abc;room-id=1;user-id=2 :spddl!spddl@spddl.tmi.twitch.tv PRIVMSG #spddl :hi there`)
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"Command":"PRIVMSG"`, `"Params":["#spddl","hi there"]`, `"Prefix":"spddl!spddl@spddl.tmi.twitch.tv"`, `"display-name":"spddl"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s does not contain %s", data, want)
+		}
+	}
+
+	var decoded IRCMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !equalIRCMessage(&msg, &decoded) || !bytes.Equal(msg.Raw, decoded.Raw) {
+		t.Errorf("decoded %+v", decoded)
+	}
+}
+
+func TestIRCMessageJSONRawBytes(t *testing.T) {
+	msg := IRCMessage{Raw: []byte("PRIVMSG #spddl :\xff\xfe"), Command: []byte("PRIVMSG")}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded IRCMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded.Raw, msg.Raw) {
+		t.Errorf("Raw = %q, want %q", decoded.Raw, msg.Raw)
+	}
+}
+
+func TestIRCMessageJSONRawString(t *testing.T) {
+	data, err := json.Marshal(IRCMessage{Raw: []byte("PRIVMSG #spddl :Kappa"), Command: []byte("PRIVMSG")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); !strings.Contains(s, `"Raw":"PRIVMSG #spddl :Kappa"`) || strings.Contains(s, "RawBase64") {
+		t.Errorf("JSON = %s", s)
+	}
+}
+
+func TestTypedEventJSON(t *testing.T) {
+	pm := ParsePrivateMessage(mustParse(t, `@badges=subscriber/12;emotes=25:0-4;tmi-sent-ts=1601973767402 :spddl!spddl@spddl.tmi.twitch.tv PRIVMSG #spddl :Kappa`))
+
+	data, err := json.Marshal(pm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded PrivateMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Text != "Kappa" || decoded.Badges.Version("subscriber") != "12" || !decoded.Time.Equal(pm.Time) || len(decoded.Emotes) != 1 {
+		t.Errorf("decoded %+v", decoded)
+	}
+	if string(decoded.IRCMessage.Command) != "PRIVMSG" {
+		t.Errorf("decoded IRCMessage %+v", decoded.IRCMessage)
+	}
+
+	raid := ParseUserNotice(mustParse(t, `@login=foo;msg-id=raid;msg-param-viewerCount=3 :tmi.twitch.tv USERNOTICE #spddl`))
+	data, err = json.Marshal(raid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"ViewerCount":3`) || !strings.Contains(string(data), `"MsgID":"raid"`) {
+		t.Errorf("raid = %s", data)
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONLWriter(&buf)
+
+	for _, line := range bytes.Split(readFile("chatlog_test.log"), []byte{13, 10})[:50] {
+		if msg, err := parseIRCMessage(line); err == nil {
+			w.WriteIRCMessage(*msg)
+		}
+	}
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	scanner.Buffer(nil, 1<<20)
+	var lines int
+	for scanner.Scan() {
+		var msg IRCMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("line %d: %v", lines, err)
+		}
+		lines++
+	}
+	if lines != 50 {
+		t.Errorf("got %d lines, want 50", lines)
+	}
+}
@@ -0,0 +1,87 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// MarshalIRC encodes the message as a wire line without the trailing "\r\n".
+// Tags are escaped and sorted by key, Raw is ignored.
+// Parsing the result with parseIRCMessage returns the same Tags, Prefix, Command and Params.
+func (m IRCMessage) MarshalIRC() ([]byte, error) {
+	if len(m.Command) == 0 {
+		return nil, fmt.Errorf("MarshalIRC: missing command")
+	}
+	if bytes.ContainsAny(m.Command, " \r\n\x00") || m.Command[0] == 58 || m.Command[0] == 64 { // : @
+		return nil, fmt.Errorf("MarshalIRC: invalid command %q", m.Command)
+	}
+	if bytes.ContainsAny(m.Prefix, " \r\n\x00") {
+		return nil, fmt.Errorf("MarshalIRC: invalid prefix %q", m.Prefix)
+	}
+
+	var buf bytes.Buffer
+
+	if len(m.Tags) != 0 {
+		keys := make([]string, 0, len(m.Tags))
+		for key := range m.Tags {
+			if key == "" || bytes.ContainsAny([]byte(key), "=; \r\n\x00") {
+				return nil, fmt.Errorf("MarshalIRC: invalid tag key %q", key)
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte(64) // @
+		for i, key := range keys {
+			if i != 0 {
+				buf.WriteByte(59) // ;
+			}
+			buf.WriteString(key)
+			if value := m.Tags[key]; len(value) != 0 {
+				buf.WriteByte(61) // =
+				buf.Write(EscapeTagValue(value))
+			}
+		}
+		buf.WriteByte(32)
+	}
+
+	if len(m.Prefix) != 0 {
+		buf.WriteByte(58) // :
+		buf.Write(m.Prefix)
+		buf.WriteByte(32)
+	}
+
+	buf.Write(m.Command)
+
+	for i, param := range m.Params {
+		if bytes.ContainsAny(param, "\r\n\x00") {
+			return nil, fmt.Errorf("MarshalIRC: param %d contains a line break", i)
+		}
+
+		buf.WriteByte(32)
+		if i == len(m.Params)-1 {
+			// The last param is sent as trailing param if it has to be,
+			// or if it follows other params like the text of a PRIVMSG.
+			if len(param) == 0 || param[0] == 58 || bytes.IndexByte(param, 32) != -1 || i != 0 {
+				buf.WriteByte(58)
+			}
+		} else if len(param) == 0 || param[0] == 58 || bytes.IndexByte(param, 32) != -1 {
+			return nil, fmt.Errorf("MarshalIRC: invalid middle param %d %q", i, param)
+		}
+		buf.Write(param)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Bytes is MarshalIRC for messages known to be valid, it returns nil otherwise
+func (m IRCMessage) Bytes() []byte {
+	line, err := m.MarshalIRC()
+	if err != nil {
+		return nil
+	}
+	return line
+}
@@ -0,0 +1,113 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"bytes"
+	"testing"
+)
+
+func equalIRCMessage(a, b *IRCMessage) bool {
+	if !bytes.Equal(a.Command, b.Command) || !bytes.Equal(a.Prefix, b.Prefix) {
+		return false
+	}
+	if len(a.Tags) != len(b.Tags) || len(a.Params) != len(b.Params) {
+		return false
+	}
+	for key, value := range a.Tags {
+		other, ok := b.Tags[key]
+		if !ok || !bytes.Equal(value, other) {
+			return false
+		}
+	}
+	for i := range a.Params {
+		if !bytes.Equal(a.Params[i], b.Params[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMarshalIRC(t *testing.T) {
+	tests := []struct {
+		msg  IRCMessage
+		want string
+	}{
+		{
+			IRCMessage{Command: []byte("PRIVMSG"), Params: [][]byte{[]byte("#spddl"), []byte("hi")}},
+			`PRIVMSG #spddl :hi`,
+		},
+		{
+			IRCMessage{Command: []byte("JOIN"), Params: [][]byte{[]byte("#spddl")}},
+			`JOIN #spddl`,
+		},
+		{
+			IRCMessage{
+				Tags:    map[string][]byte{"reply-parent-msg-id": []byte("abc"), "client-nonce": []byte("a b;c"), "flag": {}},
+				Prefix:  []byte("spddl!spddl@spddl.tmi.twitch.tv"),
+				Command: []byte("PRIVMSG"),
+				Params:  [][]byte{[]byte("#spddl"), []byte(":D")},
+			},
+			`@client-nonce=a\sb\:c;flag;reply-parent-msg-id=abc :spddl!spddl@spddl.tmi.twitch.tv PRIVMSG #spddl ::D`,
+		},
+		{
+			IRCMessage{Command: []byte("PING"), Params: [][]byte{[]byte("tmi.twitch.tv")}},
+			`PING tmi.twitch.tv`,
+		},
+		{
+			IRCMessage{Command: []byte("CAP"), Params: [][]byte{[]byte("REQ"), []byte("twitch.tv/tags twitch.tv/commands")}},
+			`CAP REQ :twitch.tv/tags twitch.tv/commands`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.msg.MarshalIRC()
+		if err != nil {
+			t.Errorf("%s: %v", tt.want, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("MarshalIRC() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestMarshalIRCInvalid(t *testing.T) {
+	for _, msg := range []IRCMessage{
+		{},
+		{Command: []byte("PRIV MSG")},
+		{Command: []byte("PRIVMSG"), Params: [][]byte{[]byte("#a b"), []byte("hi")}},
+		{Command: []byte("PRIVMSG"), Params: [][]byte{[]byte("#spddl"), []byte("hi\r\nQUIT")}},
+		{Command: []byte("PRIVMSG"), Tags: map[string][]byte{"a=b": nil}},
+		{Command: []byte("PRIVMSG"), Prefix: []byte("a b")},
+	} {
+		if line, err := msg.MarshalIRC(); err == nil {
+			t.Errorf("MarshalIRC(%+v) = %q, want error", msg, line)
+		}
+		if msg.Bytes() != nil {
+			t.Errorf("Bytes(%+v) != nil", msg)
+		}
+	}
+}
+
+func TestMarshalIRCRoundTrip(t *testing.T) {
+	for _, line := range bytes.Split(readFile("chatlog_test.log"), []byte{13, 10}) {
+		if len(line) == 0 {
+			continue
+		}
+		msg, err := parseIRCMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := msg.MarshalIRC()
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		again, err := parseIRCMessage(encoded)
+		if err != nil {
+			t.Fatalf("%s: %v", encoded, err)
+		}
+		if !equalIRCMessage(msg, again) {
+			t.Errorf("round trip\n%s\n%s", line, encoded)
+		}
+	}
+}
@@ -6,8 +6,20 @@ package twitch
 
 import (
 	"bytes"
+	"fmt"
 )
 
+// ParseError is returned for lines that are not valid IRC messages
+type ParseError struct {
+	Offset int    // byte offset in Raw where the parser gave up
+	Reason string // what was expected at Offset
+	Raw    []byte // the complete line
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parseIRCMessage: %s at offset %d: %q", e.Reason, e.Offset, e.Raw)
+}
+
 type IRCMessage struct {
 	Raw     []byte
 	Tags    map[string][]byte
@@ -16,13 +28,50 @@ type IRCMessage struct {
 	Prefix  []byte
 }
 
+// Source is the prefix of a message, server prefixes like tmi.twitch.tv only have a Host
+type Source struct {
+	Nick string
+	User string
+	Host string
+}
+
+// Source parses the prefix nick!user@host
+func (m IRCMessage) Source() Source {
+	var source Source
+	prefix := m.Prefix
+
+	if i := bytes.IndexByte(prefix, 64); i != -1 { // @
+		source.Host = string(prefix[i+1:])
+		prefix = prefix[:i]
+	} else if bytes.IndexByte(prefix, 33) == -1 { // no ! either, it's a server
+		source.Host = string(prefix)
+		return source
+	}
+
+	if i := bytes.IndexByte(prefix, 33); i != -1 { // !
+		source.User = string(prefix[i+1:])
+		prefix = prefix[:i]
+	}
+	source.Nick = string(prefix)
+	return source
+}
+
 func parseIRCMessage(data []byte) (*IRCMessage, error) {
+	return parseIRCMessageOptions(data, false)
+}
+
+// parseIRCMessageOptions parses data, if rawTagValues is set the tag values are kept escaped as received
+func parseIRCMessageOptions(data []byte, rawTagValues bool) (*IRCMessage, error) {
 	message := IRCMessage{
 		Raw:    data,
 		Tags:   map[string][]byte{},
 		Params: [][]byte{},
 	}
 
+	if len(data) == 0 {
+		return nil, &ParseError{Offset: 0, Reason: "empty message", Raw: data}
+	}
+
 	// position and nextspace are used by the parser as a reference.
 	var position int
 	var nextspace int
@@ -34,7 +83,7 @@ func parseIRCMessage(data []byte) (*IRCMessage, error) {
 		var nextspace = bytes.Index(data, []byte{32})
 		if nextspace == -1 {
 			// Malformed IRC message.
-			return &message, nil
+			return nil, &ParseError{Offset: len(data), Reason: "no command after tags", Raw: data}
 		}
 
 		// Tags are split by a semi colon.
@@ -42,15 +91,19 @@ func parseIRCMessage(data []byte) (*IRCMessage, error) {
 
 		for i := 0; i < len(rawTags); i++ {
 			// Tags delimited by an equals sign are key=value tags.
-			// If there's no equals, we assign the tag a value of true.
+			// If there's no equals, we assign the tag an empty value.
 			var tag = rawTags[i]
-			var pair = bytes.Split(tag, []byte{61})
+			var equals = bytes.IndexByte(tag, 61)
 
-			if len(pair[1]) == 0 {
-				// message.Tags[string(pair[0])] = []byte{116, 114, 117, 101} // true string
-				message.Tags[string(pair[0])] = []byte{} // empty string
+			if equals == -1 {
+				message.Tags[string(tag)] = []byte{} // empty string
+			} else if equals == len(tag)-1 {
+				// message.Tags[string(tag[:equals])] = []byte{116, 114, 117, 101} // true string
+				message.Tags[string(tag[:equals])] = []byte{} // empty string
+			} else if rawTagValues {
+				message.Tags[string(tag[:equals])] = tag[equals+1:]
 			} else {
-				message.Tags[string(pair[0])] = pair[1]
+				message.Tags[string(tag[:equals])] = UnescapeTagValue(tag[equals+1:])
 			}
 		}
 
@@ -58,8 +111,9 @@ func parseIRCMessage(data []byte) (*IRCMessage, error) {
 	}
 
 	// Skip any trailing whitespace.
-	for data[position] == 32 {
-		position++
+	position = skipSpaces(data, position)
+	if position == len(data) {
+		return nil, &ParseError{Offset: position, Reason: "missing command", Raw: data}
 	}
 
 	// Extract the message's prefix if present. Prefixes are prepended
@@ -74,15 +128,16 @@ func parseIRCMessage(data []byte) (*IRCMessage, error) {
 		// malformed.
 		if nextspace == -1 {
 			// Malformed IRC message.
-			return &message, nil
+			return nil, &ParseError{Offset: len(data), Reason: "no command after prefix", Raw: data}
 		}
 
 		message.Prefix = data[position+1 : nextspace]
 		position = nextspace + 1
 
 		// Skip any trailing whitespace.
-		for data[position] == 32 {
-			position++
+		position = skipSpaces(data, position)
+		if position == len(data) {
+			return nil, &ParseError{Offset: position, Reason: "missing command", Raw: data}
 		}
 	}
 
@@ -94,12 +149,8 @@ func parseIRCMessage(data []byte) (*IRCMessage, error) {
 	// If there's no more whitespace left, extract everything from the
 	// current position to the end of the string as the command.
 	if nextspace == -1 {
-		if len(data) > position {
-			message.Command = data[position:]
-			return &message, nil
-		}
-
-		return nil, nil
+		message.Command = data[position:]
+		return &message, nil
 	}
 
 	// Else, the command is the current position up to the next space. After
@@ -109,9 +160,7 @@ func parseIRCMessage(data []byte) (*IRCMessage, error) {
 	position = nextspace + 1
 
 	// Skip any trailing whitespace.
-	for data[position] == 32 {
-		position++
-	}
+	position = skipSpaces(data, position)
 
 	for position < len(data) {
 		nextspace = bytes.Index(data[position:], []byte{32})
@@ -136,9 +185,7 @@ func parseIRCMessage(data []byte) (*IRCMessage, error) {
 			position = nextspace + 1
 
 			// Skip any trailing whitespace and continue looping.
-			for data[position] == 32 {
-				position++
-			}
+			position = skipSpaces(data, position)
 			continue
 		}
 
@@ -152,3 +199,70 @@ func parseIRCMessage(data []byte) (*IRCMessage, error) {
 
 	return &message, nil
 }
+
+// skipSpaces returns the index of the first non-space byte at or after position
+func skipSpaces(data []byte, position int) int {
+	for position < len(data) && data[position] == 32 {
+		position++
+	}
+	return position
+}
+
+// UnescapeTagValue decodes the IRCv3 escape sequences of a tag value (\: \s \\ \r \n)
+// https://ircv3.net/specs/extensions/message-tags#escaping-values
+func UnescapeTagValue(value []byte) []byte {
+	if bytes.IndexByte(value, 92) == -1 { // nothing to do without a backslash
+		return value
+	}
+
+	unescaped := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		if value[i] != 92 { // \
+			unescaped = append(unescaped, value[i])
+			continue
+		}
+
+		i++
+		if i == len(value) { // a trailing backslash is dropped
+			break
+		}
+
+		switch value[i] {
+		case 58: // \: => ;
+			unescaped = append(unescaped, 59)
+		case 115: // \s => space
+			unescaped = append(unescaped, 32)
+		case 92: // \\ => \
+			unescaped = append(unescaped, 92)
+		case 114: // \r => CR
+			unescaped = append(unescaped, 13)
+		case 110: // \n => LF
+			unescaped = append(unescaped, 10)
+		default: // an invalid escape drops the backslash
+			unescaped = append(unescaped, value[i])
+		}
+	}
+	return unescaped
+}
+
+// EscapeTagValue encodes a tag value for outbound messages, it is the counterpart of UnescapeTagValue
+func EscapeTagValue(value []byte) []byte {
+	escaped := make([]byte, 0, len(value))
+	for _, b := range value {
+		switch b {
+		case 59: // ;
+			escaped = append(escaped, 92, 58)
+		case 32: // space
+			escaped = append(escaped, 92, 115)
+		case 92: // \
+			escaped = append(escaped, 92, 92)
+		case 13: // CR
+			escaped = append(escaped, 92, 114)
+		case 10: // LF
+			escaped = append(escaped, 92, 110)
+		default:
+			escaped = append(escaped, b)
+		}
+	}
+	return escaped
+}
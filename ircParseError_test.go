@@ -0,0 +1,67 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import "testing"
+
+func TestParseIRCMessageMalformed(t *testing.T) {
+	tests := []struct {
+		line   string
+		offset int
+	}{
+		{``, 0},
+		{`@a=b`, 4},
+		{`@a=b `, 5},
+		{`@a=b    `, 8},
+		{`:prefix`, 7},
+		{`:prefix `, 8},
+		{`@a=b :tmi.twitch.tv `, 20},
+		{` `, 1},
+	}
+	for _, tt := range tests {
+		msg, err := parseIRCMessage([]byte(tt.line))
+		if err == nil {
+			t.Errorf("parseIRCMessage(%q) = %+v, want error", tt.line, msg)
+			continue
+		}
+		parseErr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("parseIRCMessage(%q) error %T, want *ParseError", tt.line, err)
+			continue
+		}
+		if parseErr.Offset != tt.offset {
+			t.Errorf("parseIRCMessage(%q) offset %d, want %d", tt.line, parseErr.Offset, tt.offset)
+		}
+		if string(parseErr.Raw) != tt.line {
+			t.Errorf("parseIRCMessage(%q) raw %q", tt.line, parseErr.Raw)
+		}
+	}
+}
+
+func TestParseIRCMessageTrailingSpaces(t *testing.T) {
+	msg, err := parseIRCMessage([]byte(`:tmi.twitch.tv PING  `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg.Command) != "PING" || len(msg.Params) != 0 {
+		t.Errorf("got command %q params %q", msg.Command, msg.Params)
+	}
+}
+
+func TestParserOnParseError(t *testing.T) {
+	var got []*ParseError
+	var privmsg int
+	c := &Client{
+		OnParseError:     func(err *ParseError) { got = append(got, err) },
+		OnPrivateMessage: func(message IRCMessage) { privmsg++ },
+	}
+
+	c.parser([]byte("@a=b \r\n:spddl!spddl@spddl.tmi.twitch.tv PRIVMSG #spddl :hi\r\n:prefix \r\n"))
+
+	if len(got) != 2 {
+		t.Fatalf("OnParseError called %d times, want 2", len(got))
+	}
+	if privmsg != 1 {
+		t.Errorf("OnPrivateMessage called %d times, want 1", privmsg)
+	}
+}
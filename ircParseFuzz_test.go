@@ -0,0 +1,48 @@
+// +build windows linux js,wasm
+// +build go1.18
+
+package twitch
+
+import (
+	"bytes"
+	"testing"
+)
+
+// testing.F needs Go 1.18
+func FuzzParseIRCMessage(f *testing.F) {
+	for _, line := range bytes.Split(readFile("chatlog_test.log"), []byte{13, 10}) {
+		f.Add(line)
+	}
+	f.Add([]byte(`@a=b `))
+	f.Add([]byte(`:prefix `))
+	f.Add([]byte(`@a;b=;=c PRIVMSG  #x  y :z`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		msg, err := parseIRCMessage(data)
+		if err != nil {
+			parseErr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("error %T, want *ParseError", err)
+			}
+			if parseErr.Offset < 0 || parseErr.Offset > len(data) {
+				t.Fatalf("offset %d out of range for %q", parseErr.Offset, data)
+			}
+			return
+		}
+		if len(msg.Command) == 0 {
+			t.Fatalf("empty command for %q", data)
+		}
+
+		encoded, err := msg.MarshalIRC()
+		if err != nil {
+			return // e.g. line breaks in the trailing param
+		}
+		again, err := parseIRCMessage(encoded)
+		if err != nil {
+			t.Fatalf("%q encoded as %q: %v", data, encoded, err)
+		}
+		if !equalIRCMessage(msg, again) {
+			t.Fatalf("%q encoded as %q does not round trip", data, encoded)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUnescapeTagValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{``, ``},
+		{`plain`, `plain`},
+		{`Channel\sSubscription\s(gronkhtv)`, `Channel Subscription (gronkhtv)`},
+		{`a\:b`, `a;b`},
+		{`back\\slash`, `back\slash`},
+		{`line\rbreak\n`, "line\rbreak\n"},
+		{`invalid\xescape`, `invalidxescape`},
+		{`trailing\`, `trailing`},
+		{`ja\sein\stolles\sGame\ssein\s\:)`, `ja ein tolles Game sein ;)`},
+	}
+	for _, tt := range tests {
+		if got := UnescapeTagValue([]byte(tt.in)); string(got) != tt.want {
+			t.Errorf("UnescapeTagValue(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeTagValueRoundTrip(t *testing.T) {
+	for _, value := range []string{``, `plain`, `a b;c\d`, "cr\rlf\n", `\s`} {
+		escaped := EscapeTagValue([]byte(value))
+		if bytes.ContainsAny(escaped, " ;\r\n") {
+			t.Errorf("EscapeTagValue(%q) = %q contains a reserved character", value, escaped)
+		}
+		if got := UnescapeTagValue(escaped); string(got) != value {
+			t.Errorf("UnescapeTagValue(EscapeTagValue(%q)) = %q", value, got)
+		}
+	}
+}
+
+func TestParseIRCMessageTagEscapes(t *testing.T) {
+	data := readFile("chatlog_test.log")
+	var checked int
+	for _, line := range bytes.Split(data, []byte{13, 10}) {
+		if len(line) == 0 || line[0] != 64 {
+			continue
+		}
+
+		msg, err := parseIRCMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := parseIRCMessageOptions(line, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for key, value := range msg.Tags {
+			if bytes.Contains(value, []byte{92, 115}) { // \s
+				t.Errorf("tag %s still escaped: %q", key, value)
+			}
+			if !bytes.Equal(UnescapeTagValue(raw.Tags[key]), value) {
+				t.Errorf("tag %s: raw %q does not decode to %q", key, raw.Tags[key], value)
+			}
+			if bytes.Contains(raw.Tags[key], []byte{92}) {
+				checked++
+			}
+		}
+	}
+	if checked == 0 {
+		t.Fatal("no escaped tag values found in chatlog_test.log")
+	}
+}
+
+func TestParseIRCMessageSystemMsg(t *testing.T) {
+	line := []byte(`@badge-info=;badges=premium/1;color=;display-name=scharcko;emotes=;flags=;id=a230bcbb-174d-4c1b-8b96-ff1b5071f43e;login=scharcko;mod=0;msg-id=sub;msg-param-cumulative-months=1;msg-param-months=0;msg-param-multimonth-duration=0;msg-param-multimonth-tenure=0;msg-param-should-share-streak=0;msg-param-sub-plan-name=Channel\sSubscription\s(gronkhtv);msg-param-sub-plan=Prime;msg-param-was-gifted=false;room-id=106159308;subscriber=1;system-msg=scharcko\ssubscribed\swith\sTwitch\sPrime.;tmi-sent-ts=1601974819657;user-id=143102219;user-type= :tmi.twitch.tv USERNOTICE #gronkhtv`)
+
+	msg, _ := parseIRCMessage(line)
+	if got := string(msg.Tags["system-msg"]); got != "scharcko subscribed with Twitch Prime." {
+		t.Errorf("system-msg = %q", got)
+	}
+	if got := string(msg.Tags["msg-param-sub-plan-name"]); got != "Channel Subscription (gronkhtv)" {
+		t.Errorf("msg-param-sub-plan-name = %q", got)
+	}
+
+	raw, _ := parseIRCMessageOptions(line, true)
+	if got := string(raw.Tags["system-msg"]); got != `scharcko\ssubscribed\swith\sTwitch\sPrime.` {
+		t.Errorf("raw system-msg = %q", got)
+	}
+}
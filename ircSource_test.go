@@ -0,0 +1,33 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   Source
+	}{
+		{"spddl!spddl@spddl.tmi.twitch.tv", Source{Nick: "spddl", User: "spddl", Host: "spddl.tmi.twitch.tv"}},
+		{"tmi.twitch.tv", Source{Host: "tmi.twitch.tv"}},
+		{"spddl.tmi.twitch.tv", Source{Host: "spddl.tmi.twitch.tv"}},
+		{"spddl@spddl.tmi.twitch.tv", Source{Nick: "spddl", Host: "spddl.tmi.twitch.tv"}},
+		{"spddl!", Source{Nick: "spddl"}},
+		{"", Source{}},
+	}
+	for _, tt := range tests {
+		if got := (IRCMessage{Prefix: []byte(tt.prefix)}).Source(); got != tt.want {
+			t.Errorf("Source(%q) = %+v, want %+v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestSourceJoin(t *testing.T) {
+	msg := mustParse(t, ":spddl!spddl@spddl.tmi.twitch.tv JOIN #gronkhtv")
+	if login := msg.Source().Nick; login != "spddl" {
+		t.Errorf("login = %q", login)
+	}
+}
@@ -0,0 +1,225 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit allows Messages per Per
+type Limit struct {
+	Messages int
+	Per      time.Duration
+}
+
+// Limits of one account, https://dev.twitch.tv/docs/irc/guide#command--message-limits
+type Limits struct {
+	Authenticate  Limit
+	Join          Limit
+	Chat          Limit // channels without Moderator or Operator status
+	ChatModOp     Limit // channels with Moderator or Operator status
+	WhisperSecond Limit
+	WhisperMinute Limit
+
+	WhisperRecipients int // different accounts per day, 0 is unlimited
+}
+
+// UserLimits are the limits of accounts that are not known or verified bots
+func UserLimits() Limits {
+	return Limits{
+		Authenticate:  Limit{20, 10 * time.Second},
+		Join:          Limit{20, 10 * time.Second},
+		Chat:          Limit{20, 30 * time.Second},
+		ChatModOp:     Limit{100, 30 * time.Second},
+		WhisperSecond: Limit{3, time.Second},
+		WhisperMinute: Limit{100, time.Minute},
+
+		WhisperRecipients: 40,
+	}
+}
+
+// KnownBotLimits only differ in the whisper limits
+func KnownBotLimits() Limits {
+	l := UserLimits()
+	l.WhisperSecond = Limit{10, time.Second}
+	l.WhisperMinute = Limit{200, time.Minute}
+	l.WhisperRecipients = 500
+	return l
+}
+
+// VerifiedBotLimits are the limits of verified bots
+func VerifiedBotLimits() Limits {
+	l := UserLimits()
+	l.Authenticate = Limit{200, 10 * time.Second}
+	l.Join = Limit{200, 10 * time.Second}
+	l.WhisperSecond = Limit{20, time.Second}
+	l.WhisperMinute = Limit{1200, time.Minute}
+	l.WhisperRecipients = 100000
+	return l
+}
+
+// Clock is the time source of the limiters, tests replace it
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// AccountLimiter holds the rate limit state of one account.
+// Clients that log in with the same account should share it (see AccountLimiters).
+type AccountLimiter struct {
+	limits Limits
+	clock  Clock
+
+	mu            sync.Mutex // a message is booked in all its windows at once
+	authenticate  window
+	join          window
+	chat          window
+	chatModOp     window
+	whisperSecond window
+	whisperMinute window
+
+	recipients MemoryWhisperStore // unless Client.WhisperStore is set
+}
+
+func NewAccountLimiter(limits Limits) *AccountLimiter {
+	return NewAccountLimiterClock(limits, systemClock{})
+}
+
+// NewAccountLimiterClock uses clock instead of the system time
+func NewAccountLimiterClock(limits Limits, clock Clock) *AccountLimiter {
+	return &AccountLimiter{
+		limits:        limits,
+		clock:         clock,
+		authenticate:  window{limit: limits.Authenticate},
+		join:          window{limit: limits.Join},
+		chat:          window{limit: limits.Chat},
+		chatModOp:     window{limit: limits.ChatModOp},
+		whisperSecond: window{limit: limits.WhisperSecond},
+		whisperMinute: window{limit: limits.WhisperMinute},
+	}
+}
+
+// Limits returns the limits the AccountLimiter was created with
+func (l *AccountLimiter) Limits() Limits {
+	return l.limits
+}
+
+// reserve books the next message in all windows and returns when it may be sent
+// and the window that delays it, nil if it may be sent right away
+func (l *AccountLimiter) reserve(windows ...*window) (time.Time, *window) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	at := l.clock.Now()
+	var limiting *window
+	for _, w := range windows {
+		if next := w.next(); next.After(at) {
+			at = next
+			limiting = w
+		}
+	}
+	for _, w := range windows {
+		w.book(at)
+	}
+	return at, limiting
+}
+
+// wait blocks until the next message may be sent, false if ctx is done first
+func (l *AccountLimiter) wait(ctx context.Context, template string, windows ...*window) bool {
+	at, limiting := l.reserve(windows...)
+	if limiting == nil {
+		return true
+	}
+
+	log.Printf(template, limiting.limit.Messages)
+	select {
+	case <-l.clock.After(at.Sub(l.clock.Now())):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// AccountLimiters hands out one AccountLimiter per login
+type AccountLimiters struct {
+	Limits Limits // of new logins, the zero value is UserLimits
+
+	mu       sync.Mutex
+	limiters map[string]*AccountLimiter
+}
+
+// For returns the AccountLimiter of login, logins are case-insensitive
+func (a *AccountLimiters) For(login string) *AccountLimiter {
+	login = strings.ToLower(login)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.limiters == nil {
+		a.limiters = map[string]*AccountLimiter{}
+	}
+	if l, ok := a.limiters[login]; ok {
+		return l
+	}
+
+	limits := a.Limits
+	if limits == (Limits{}) {
+		limits = UserLimits()
+	}
+	l := NewAccountLimiter(limits)
+	a.limiters[login] = l
+	return l
+}
+
+// limiter returns Client.Limiter, nil is replaced by one that matches BotVerified and BotKnown
+func (c *Client) limiter() *AccountLimiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Limiter == nil {
+		switch {
+		case c.BotVerified:
+			c.Limiter = NewAccountLimiter(VerifiedBotLimits())
+		case c.BotKnown:
+			c.Limiter = NewAccountLimiter(KnownBotLimits())
+		default:
+			c.Limiter = NewAccountLimiter(UserLimits())
+		}
+	}
+	return c.Limiter
+}
+
+// window is a sliding window log: a message may be sent once fewer than
+// limit.Messages were sent during the last limit.Per
+type window struct {
+	limit  Limit
+	sent   []time.Time // ring of the last limit.Messages send times
+	oldest int
+}
+
+// next returns the earliest time for the next message, zero if there is room
+func (w *window) next() time.Time {
+	if w.limit.Messages <= 0 || len(w.sent) < w.limit.Messages {
+		return time.Time{}
+	}
+	return w.sent[w.oldest].Add(w.limit.Per)
+}
+
+func (w *window) book(at time.Time) {
+	if w.limit.Messages <= 0 { // unlimited
+		return
+	}
+	if len(w.sent) < w.limit.Messages {
+		w.sent = append(w.sent, at)
+		return
+	}
+	w.sent[w.oldest] = at
+	w.oldest = (w.oldest + 1) % len(w.sent)
+}
@@ -0,0 +1,227 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAccountLimiters(t *testing.T) {
+	var limiters AccountLimiters
+	a := limiters.For("spddl")
+	if limiters.For("SPDDL") != a {
+		t.Error("the same login got two limiters")
+	}
+	if limiters.For("other") == a {
+		t.Error("two logins share a limiter")
+	}
+	if a.Limits() != UserLimits() {
+		t.Errorf("Limits() = %+v", a.Limits())
+	}
+
+	custom := AccountLimiters{Limits: Limits{Chat: Limit{1, time.Second}}}
+	if got := custom.For("spddl").Limits().Chat; got != (Limit{1, time.Second}) {
+		t.Errorf("Chat = %+v", got)
+	}
+}
+
+func TestClientLimiter(t *testing.T) {
+	for _, tt := range []struct {
+		client *Client
+		want   Limits
+	}{
+		{&Client{}, UserLimits()},
+		{&Client{BotKnown: true}, KnownBotLimits()},
+		{&Client{BotVerified: true, BotKnown: true}, VerifiedBotLimits()},
+	} {
+		if got := tt.client.limiter().Limits(); got != tt.want {
+			t.Errorf("BotVerified %v, BotKnown %v: %+v", tt.client.BotVerified, tt.client.BotKnown, got)
+		}
+	}
+
+	shared := NewAccountLimiter(Limits{Chat: Limit{5, time.Second}})
+	a, b := &Client{Limiter: shared}, &Client{}
+	if a.limiter() != shared || b.limiter() == shared {
+		t.Error("Client.Limiter is not used")
+	}
+}
+
+// fakeClock only moves on Advance
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.c <- c.now
+	} else {
+		c.waiters = append(c.waiters, timer)
+	}
+	return timer.c
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, timer := range c.waiters {
+		if timer.at.After(c.now) {
+			waiters = append(waiters, timer)
+		} else {
+			timer.c <- c.now
+		}
+	}
+	c.waiters = waiters
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l := NewAccountLimiterClock(Limits{Chat: Limit{2, 10 * time.Second}}, clock)
+
+	for i, want := range []time.Duration{0, 0, 10 * time.Second, 10 * time.Second, 20 * time.Second} {
+		if i == 3 {
+			clock.Advance(3 * time.Second) // the delay is still counted from the first message
+		}
+		at, _ := l.reserve(&l.chat)
+		if got := at.Sub(time.Unix(0, 0)); got != want {
+			t.Errorf("message %d at %v, want %v", i, got, want)
+		}
+	}
+
+	clock.Advance(time.Minute)
+	if at, limiting := l.reserve(&l.chat); !at.Equal(clock.Now()) || limiting != nil {
+		t.Errorf("after an idle minute: %v, %v", at, limiting)
+	}
+}
+
+func TestReserveAllWindows(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l := NewAccountLimiterClock(Limits{
+		WhisperSecond: Limit{2, time.Second},
+		WhisperMinute: Limit{3, time.Minute},
+	}, clock)
+
+	for i, want := range []time.Duration{0, 0, time.Second, time.Minute, time.Minute} {
+		at, _ := l.reserve(&l.whisperSecond, &l.whisperMinute)
+		if got := at.Sub(time.Unix(0, 0)); got != want {
+			t.Errorf("whisper %d at %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l := NewAccountLimiterClock(Limits{Join: Limit{1, 10 * time.Second}}, clock)
+	ctx := context.Background()
+
+	if !l.wait(ctx, "%d", &l.join) {
+		t.Fatal("the first JOIN waited")
+	}
+
+	done := make(chan bool)
+	go func() {
+		done <- l.wait(ctx, "%d", &l.join)
+	}()
+	for { // until the second JOIN waits for the clock
+		clock.mu.Lock()
+		n := len(clock.waiters)
+		clock.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	clock.Advance(9 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("the second JOIN was sent too early")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+	if !<-done {
+		t.Error("wait = false")
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if l.wait(canceled, "%d", &l.join) {
+		t.Error("wait with a canceled context = true")
+	}
+}
+
+// lineTransport records the written lines
+type lineTransport struct {
+	lines chan string
+}
+
+func (t *lineTransport) Dial(ctx context.Context, server string, opts *DialOptions) error {
+	return nil
+}
+
+func (t *lineTransport) ReadFrame(ctx context.Context) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (t *lineTransport) WriteLine(ctx context.Context, line []byte) error {
+	t.lines <- string(line)
+	return nil
+}
+
+func (t *lineTransport) Close() error {
+	return nil
+}
+
+func TestWriteLimitedAfterReconnect(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	conn := &lineTransport{lines: make(chan string, 2)}
+	c := &Client{Limiter: NewAccountLimiterClock(Limits{Chat: Limit{1, 30 * time.Second}}, clock), conn: conn}
+	c.context, c.cancel = context.WithCancel(context.Background())
+	defer c.cancel()
+	c.setState(Ready)
+	l := c.limiter()
+
+	c.writeLimited([]byte("first"), "%d", &l.chat)
+	go c.writeLimited([]byte("second"), "%d", &l.chat)
+	waitForTimer(t, clock)
+
+	c.setState(Reconnecting)
+	clock.Advance(30 * time.Second) // the slot of the second line passes while the client is down
+	time.Sleep(10 * time.Millisecond)
+	c.setState(Ready)
+	waitForTimer(t, clock) // books a new slot instead of writing right away
+
+	if got := <-conn.lines; got != "first" {
+		t.Fatalf("first write %q", got)
+	}
+	select {
+	case got := <-conn.lines:
+		t.Fatalf("%q was written with the slot booked before the reconnect", got)
+	default:
+	}
+	clock.Advance(30 * time.Second)
+	if got := <-conn.lines; got != "second" {
+		t.Errorf("second write %q", got)
+	}
+}
@@ -8,38 +8,64 @@ import (
 	"time"
 )
 
-func (c *Client) write(msg []byte) {
+// writeLimited waits until the client is Ready and the limiter has a slot, only the login is sent before (see writeLogin).
+// The slot is booked right before the write, a line whose connection went down meanwhile books a new one once Ready again
+func (c *Client) writeLimited(msg []byte, template string, windows ...*window) (Transport, error) {
+	l := c.limiter()
+	for {
+		if err := c.WaitReady(c.context); err != nil {
+			return nil, err
+		}
+		if !l.wait(c.context, template, windows...) {
+			return nil, ErrClosed
+		}
+		if conn := c.getConn(); conn != nil && c.State() == Ready {
+			return conn, c.writeTo(conn, msg)
+		}
+	}
+}
+
+// writeLogin sends CAP/PASS/NICK to a connection that waits for them
+func (c *Client) writeLogin(msg []byte) {
+	if state := c.State(); state == Authenticating || state == Ready {
+		c.writeConn(msg)
+	}
+}
+
+// writeConn writes to the current connection regardless of the state
+func (c *Client) writeConn(msg []byte) {
+	if conn := c.getConn(); conn != nil {
+		_ = c.writeTo(conn, msg)
+	}
+}
+
+// writeTo writes to conn, a failed write reconnects if conn is the current connection
+func (c *Client) writeTo(conn Transport, msg []byte) error {
 	select {
 	case <-c.context.Done():
 		c.Close()
-		return
+		return c.context.Err()
 	default:
-		if c.IsConnected() {
-			w, err := c.conn.Writer(c.context, 1 /* websocket.MessageText */)
-			if err != nil {
-				c.CloseAndReconnect()
-				return
-			}
-
-			_, err = w.Write(append(msg, []byte{13, 10}...))
-			if err != nil {
-				log.Println(err)
-				return
-			}
-
-			err = w.Close()
-			if err != nil {
-				log.Println(err)
-				return
-			}
-			if c.Debug {
-				log.Printf(debugTemplate, msg)
+		if err := conn.WriteLine(c.context, msg); err != nil {
+			log.Println(err)
+			if c.getConn() == conn {
+				c.reconnectAfter(err)
 			}
+			return err
 		}
+		if c.Debug {
+			log.Printf(debugTemplate, msg)
+		}
+		return nil
 	}
 }
 
 func (c *Client) parser(msgData []byte) {
+	c.parse(c.getConn(), msgData)
+}
+
+// parse dispatches the lines read from conn
+func (c *Client) parse(conn Transport, msgData []byte) {
 	msg := bytes.Split(msgData, []byte{13, 10}) // "\r\n"
 	for _, v := range msg {
 		if len(v) == 0 {
@@ -48,10 +74,18 @@ func (c *Client) parser(msgData []byte) {
 
 		// log.Println(string(v))
 
-		ircMsg, err := parseIRCMessage(v)
+		ircMsg, err := parseIRCMessageOptions(v, c.RawTags)
 		if err != nil {
-			log.Println("parseIRCMessage:", err)
-			return
+			if parseErr, ok := err.(*ParseError); ok && c.OnParseError != nil {
+				c.OnParseError(parseErr)
+			} else {
+				log.Println(err)
+			}
+			continue
+		}
+
+		if c.duplicate(*ircMsg) {
+			continue
 		}
 
 		switch {
@@ -59,6 +93,9 @@ func (c *Client) parser(msgData []byte) {
 			if c.OnPrivateMessage != nil {
 				c.OnPrivateMessage(*ircMsg)
 			}
+			if c.OnPrivMsg != nil {
+				c.OnPrivMsg(ParsePrivateMessage(*ircMsg))
+			}
 
 		case bytes.Equal(ircMsg.Command, []byte{87, 72, 73, 83, 80, 69, 82}): // WHISPER
 			if c.OnWhisperMessage != nil {
@@ -70,9 +107,24 @@ func (c *Client) parser(msgData []byte) {
 				log.Printf(debugTemplate, v)
 			}
 
+			if c.handoverOf(conn) { // the handover joins the channels itself
+				c.loginResult(conn, nil)
+				break
+			}
+			c.setState(Ready)
+			c.loginResult(conn, nil)
+			c.rejoin()
 			if c.OnConnect != nil {
 				c.OnConnect(true)
 			}
+			c.mu.Lock()
+			reconnected := c.reconnected
+			c.reconnected = false
+			c.authFailures = 0
+			c.mu.Unlock()
+			if reconnected && c.OnReconnected != nil {
+				c.OnReconnected()
+			}
 
 		case bytes.Equal(ircMsg.Command, []byte{48, 48, 50}): // RPL_YOURHOST (002) Your host is tmi.twitch.tv
 			if c.Debug {
@@ -125,6 +177,8 @@ func (c *Client) parser(msgData []byte) {
 			}
 
 		case bytes.Equal(ircMsg.Command, []byte{78, 79, 84, 73, 67, 69}): // NOTICE
+			c.authNotice(conn, *ircMsg)
+			c.resolveSend(conn, *ircMsg)
 			if c.OnNoticeMessage != nil {
 				c.OnNoticeMessage(*ircMsg)
 			}
@@ -135,11 +189,14 @@ func (c *Client) parser(msgData []byte) {
 			}
 
 		case bytes.Equal(ircMsg.Command, []byte{85, 83, 69, 82, 83, 84, 65, 84, 69}): // USERSTATE
+			c.membershipOn(conn, *ircMsg)
+			c.resolveSend(conn, *ircMsg)
 			if c.OnUserStateMessage != nil {
 				c.OnUserStateMessage(*ircMsg)
 			}
 
 		case bytes.Equal(ircMsg.Command, []byte{82, 79, 79, 77, 83, 84, 65, 84, 69}): // ROOMSTATE
+			c.membershipOn(conn, *ircMsg)
 			if c.OnRoomStateMessage != nil {
 				c.OnRoomStateMessage(*ircMsg)
 			}
@@ -158,25 +215,35 @@ func (c *Client) parser(msgData []byte) {
 			if c.OnUserNoticeMessage != nil {
 				c.OnUserNoticeMessage(*ircMsg)
 			}
+			c.userNoticeEvent(*ircMsg)
 
 		case bytes.Equal(ircMsg.Command, []byte{80, 73, 78, 71}): // PING // https://blog.golang.org/concurrency-timeouts
-			if c.IsConnected() {
-				c.write([]byte{80, 79, 78, 71, 32, 58, 116, 109, 105, 46, 116, 119, 105, 116, 99, 104, 46, 116, 118, 13, 10}) // "PONG :tmi.twitch.tv\r\n"
+			if conn != nil { // on the connection that asked, it may be the new one of a handover
+				_ = c.writeTo(conn, []byte{80, 79, 78, 71, 32, 58, 116, 109, 105, 46, 116, 119, 105, 116, 99, 104, 46, 116, 118, 13, 10}) // "PONG :tmi.twitch.tv\r\n"
 			}
 
 		case bytes.Equal(ircMsg.Command, []byte{80, 79, 78, 71}): // PONG
 			c.pongReceived <- true
 
 		case bytes.Equal(ircMsg.Command, []byte{74, 79, 73, 78}): // JOIN
+			c.membershipOn(conn, *ircMsg)
 			if c.OnJoinMessage != nil {
 				c.OnJoinMessage(*ircMsg)
 			}
 
 		case bytes.Equal(ircMsg.Command, []byte{80, 65, 82, 84}): // PART
+			c.membershipOn(conn, *ircMsg)
 			if c.OnPartMessage != nil {
 				c.OnPartMessage(*ircMsg)
 			}
 
+		case bytes.Equal(ircMsg.Command, []byte{82, 69, 67, 79, 78, 78, 69, 67, 84}): // RECONNECT
+			if c.Debug {
+				log.Printf(debugTemplate, v)
+			}
+			c.startDedupe()
+			go c.handover()
+
 		default:
 			if c.OnUnknownMessage != nil {
 				c.OnUnknownMessage(*ircMsg)
@@ -186,181 +253,74 @@ func (c *Client) parser(msgData []byte) {
 	}
 }
 
+// sendJoin and the other send loops write through writeLimited
 func (c *Client) sendJoin(rawMsgChan <-chan string) {
+	l := c.limiter()
 	for rawMsg := range rawMsgChan {
-		_joinRateQueueLimit.add()
-		go func() {
-			time.Sleep(time.Duration(joinRateLimitSeconds) * time.Second)
-			_joinRateQueueLimit.sub()
-		}()
-
-		if c.BotVerified {
-			if _joinRateQueueLimit.get() >= verifiedJoinRateLimitMessages {
-				log.Printf(joinRateQueueLimitTemplate, verifiedJoinRateLimitMessages)
-				time.Sleep(time.Duration(joinRateLimitSeconds) * time.Second)
-			}
-		} else {
-			if _joinRateQueueLimit.get() >= joinRateLimitMessages {
-				log.Printf(joinRateQueueLimitTemplate, joinRateLimitMessages)
-				time.Sleep(time.Duration(joinRateLimitSeconds) * time.Second)
-			}
-		}
-
-		c.write([]byte(rawMsg))
+		c.writeLimited([]byte(rawMsg), joinRateQueueLimitTemplate, &l.join)
+		c.joinSent(rawMsg)
 	}
 }
 
 func (c *Client) sendAuthenticate(rawMsgChan <-chan string) {
+	l := c.limiter()
 	for rawMsg := range rawMsgChan {
-		_authenticateRateQueueLimit.add()
-
-		go func() {
-			time.Sleep(time.Duration(authenticateRateLimitSeconds) * time.Second)
-			_authenticateRateQueueLimit.sub()
-		}()
-
-		if c.BotVerified {
-			if _authenticateRateQueueLimit.get() >= verifiedauthenticateRateLimitMessages {
-				log.Printf(authenticateRateQueueLimitTemplate, verifiedauthenticateRateLimitMessages)
-				time.Sleep(time.Duration(authenticateRateLimitSeconds) * time.Second)
-			}
-		} else {
-			if _authenticateRateQueueLimit.get() >= authenticateRateLimitMessages {
-				log.Printf(authenticateRateQueueLimitTemplate, authenticateRateLimitMessages)
-				time.Sleep(time.Duration(authenticateRateLimitSeconds) * time.Second)
-			}
+		if !l.wait(c.context, authenticateRateQueueLimitTemplate, &l.authenticate) {
+			continue
 		}
-
-		c.write([]byte(rawMsg))
+		c.writeLogin([]byte(rawMsg))
 	}
 }
 
 func (c *Client) send(rawMsgChan <-chan string) {
+	l := c.limiter()
 	for rawMsg := range rawMsgChan {
-		_queueRateLimit.add()
-		go func() {
-			time.Sleep(time.Duration(rateLimitSeconds) * time.Second)
-			_queueRateLimit.sub()
-		}()
-
-		if _queueRateLimit.get() >= rateLimitMessages {
-			log.Printf(queueRateLimitTemplate, rateLimitMessages)
-			time.Sleep(time.Duration(rateLimitSeconds) * time.Second)
-		}
-
-		c.write([]byte(rawMsg))
+		conn, err := c.writeLimited([]byte(rawMsg), queueRateLimitTemplate, &l.chat)
+		c.privmsgDone(conn, rawMsg, err)
 	}
 }
 
 func (c *Client) sendModOp(rawMsgChan <-chan string) {
+	l := c.limiter()
 	for rawMsg := range rawMsgChan {
-		_queueRateLimitModOp.add()
-		go func() {
-			time.Sleep(time.Duration(rateLimitModOpSeconds) * time.Second)
-			_queueRateLimitModOp.sub()
-		}()
-
-		if _queueRateLimitModOp.get() >= rateLimitModOpMessages {
-			log.Printf(queueRateLimitModOpTemplate, rateLimitModOpMessages)
-			time.Sleep(time.Duration(rateLimitModOpSeconds) * time.Second)
-		}
-		c.write([]byte(rawMsg))
+		conn, err := c.writeLimited([]byte(rawMsg), queueRateLimitModOpTemplate, &l.chatModOp)
+		c.privmsgDone(conn, rawMsg, err)
 	}
 }
 
+// sendWhisper, sendWhisperKnownBot and sendWhisperVerifiedBots share the whisper limits of the account
 func (c *Client) sendWhisper(rawMsgChan <-chan string) {
+	l := c.limiter()
 	for rawMsg := range rawMsgChan {
-		_queueRateLimitWhisperMinute.add()
-		_queueRateLimitWhisperSeconds.add()
-		go func() {
-			time.Sleep(time.Duration(rateLimitWhisperMinute) * time.Minute)
-			_queueRateLimitWhisperMinute.sub()
-		}()
-		go func() {
-			time.Sleep(time.Duration(rateLimitWhisperSeconds) * time.Second)
-			_queueRateLimitWhisperSeconds.sub()
-		}()
-
-		if _queueRateLimitWhisperMinute.get() >= rateLimitWhisperMinuteMessages {
-			log.Printf(queueRateLimitWhisperTemplate, rateLimitWhisperMinuteMessages)
-			time.Sleep(time.Duration(rateLimitWhisperMinute) * time.Minute)
-		}
-		if _queueRateLimitWhisperSeconds.get() >= rateLimitWhisperSecondsMessages {
-			log.Printf(queueRateLimitWhisperTemplate, rateLimitWhisperSecondsMessages)
-			time.Sleep(time.Duration(rateLimitWhisperSeconds) * time.Second)
-		}
-
-		c.write([]byte(rawMsg))
+		c.writeLimited([]byte(rawMsg), queueRateLimitWhisperTemplate, &l.whisperSecond, &l.whisperMinute)
 	}
 }
 
 func (c *Client) sendWhisperKnownBot(rawMsgChan <-chan string) {
-	for rawMsg := range rawMsgChan {
-		_queueRateLimitKnownBotsWhisperMinute.add()
-		_queueRateLimitKnownBotsWhisperSeconds.add()
-		go func() {
-			time.Sleep(time.Duration(rateLimitKnownBotsWhisperMinute) * time.Minute)
-			_queueRateLimitKnownBotsWhisperMinute.sub()
-		}()
-		go func() {
-			time.Sleep(time.Duration(rateLimitKnownBotsWhisperSeconds) * time.Second)
-			_queueRateLimitKnownBotsWhisperSeconds.sub()
-		}()
-
-		if _queueRateLimitKnownBotsWhisperMinute.get() >= rateLimitKnownBotsWhisperMinuteMessages {
-			log.Printf(queueRateLimitWhisperTemplate, rateLimitKnownBotsWhisperMinuteMessages)
-			time.Sleep(time.Duration(rateLimitKnownBotsWhisperMinute) * time.Minute)
-		}
-		if _queueRateLimitKnownBotsWhisperSeconds.get() >= rateLimitKnownBotsWhisperSecondsMessages {
-			log.Printf(queueRateLimitWhisperTemplate, rateLimitKnownBotsWhisperSecondsMessages)
-			time.Sleep(time.Duration(rateLimitKnownBotsWhisperSeconds) * time.Second)
-		}
-
-		c.write([]byte(rawMsg))
-	}
+	c.sendWhisper(rawMsgChan)
 }
 
 func (c *Client) sendWhisperVerifiedBots(rawMsgChan <-chan string) {
-	for rawMsg := range rawMsgChan {
-		_queueRateLimitVerifiedBotsWhisperMinute.add()
-		_queueRateLimitVerifiedBotsWhisperSeconds.add()
-		go func() {
-			time.Sleep(time.Duration(rateLimitVerifiedBotsWhisperMinute) * time.Minute)
-			_queueRateLimitVerifiedBotsWhisperMinute.sub()
-		}()
-		go func() {
-			time.Sleep(time.Duration(rateLimitVerifiedBotsWhisperSeconds) * time.Second)
-			_queueRateLimitVerifiedBotsWhisperSeconds.sub()
-		}()
-
-		if _queueRateLimitVerifiedBotsWhisperMinute.get() >= rateLimitVerifiedBotsWhisperMinuteMessages {
-			log.Printf(queueRateLimitWhisperTemplate, rateLimitVerifiedBotsWhisperMinuteMessages)
-			time.Sleep(time.Duration(rateLimitVerifiedBotsWhisperMinute) * time.Minute)
-		}
-		if _queueRateLimitVerifiedBotsWhisperSeconds.get() >= rateLimitVerifiedBotsWhisperSecondsMessages {
-			log.Printf(queueRateLimitWhisperTemplate, rateLimitVerifiedBotsWhisperSecondsMessages)
-			time.Sleep(time.Duration(rateLimitVerifiedBotsWhisperSeconds) * time.Second)
-		}
-
-		c.write([]byte(rawMsg))
-	}
+	c.sendWhisper(rawMsgChan)
 }
 
 func (c *Client) pingPong() { // https://github.com/gempir/go-twitch-irc/blob/f5ac4c45474ea2fb0e5f1f77f0bd7bbbcc70da7c/c.go#L791
 	c.pongReceived = make(chan bool, 1)
 	var pingTime time.Time
-	if c.IsConnected() {
-		c.write([]byte{80, 73, 78, 71, 32, 58, 116, 109, 105, 46, 116, 119, 105, 116, 99, 104, 46, 116, 118, 13, 10}) // "PING :tmi.twitch.tv\r\n"
-	}
 	go func() {
 		for {
 			// About once every five minutes, the server will send you a PING :tmi.twitch.tv. To ensure that your connection to the server is not prematurely terminated, reply with PONG :tmi.twitch.tv.
-			<-time.After(3 * 60 * time.Second)
-			if c.IsConnected() {
-				c.write([]byte{80, 73, 78, 71, 32, 58, 116, 109, 105, 46, 116, 119, 105, 116, 99, 104, 46, 116, 118, 13, 10}) // "PING :tmi.twitch.tv\r\n"
-				if c.OnPongLatency != nil {
-					pingTime = time.Now()
-				}
+			select {
+			case <-c.context.Done():
+				return
+			case <-time.After(3 * 60 * time.Second):
+			}
+			if c.State() != Ready {
+				continue // nobody to ping, a reconnect is already running
+			}
+			c.writeConn([]byte{80, 73, 78, 71, 32, 58, 116, 109, 105, 46, 116, 119, 105, 116, 99, 104, 46, 116, 118, 13, 10}) // "PING :tmi.twitch.tv\r\n"
+			if c.OnPongLatency != nil {
+				pingTime = time.Now()
 			}
 			select {
 			case <-c.context.Done():
@@ -375,7 +335,7 @@ func (c *Client) pingPong() { // https://github.com/gempir/go-twitch-irc/blob/f5
 
 			case <-time.After(time.Second * 5):
 				log.Println("// No pong message was received within the pong timeout, reconnect")
-				c.CloseAndReconnect()
+				c.reconnectAfter(errPongTimeout)
 			}
 		}
 	}()
@@ -0,0 +1,231 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// outbox of a channel holds the PRIVMSGs that wait for slow mode or DuplicateDelay. They are
+// queued in order by drainOutbox, so Say and SendMessage never sleep in the caller, which may
+// be the read loop.
+type outbox struct {
+	lines   []outboxLine
+	running bool          // drainOutbox works on lines
+	queued  int           // PRIVMSGs of the channel in the chat queues, not written yet
+	done    chan struct{} // one of them was written or dropped
+	taken   chan struct{} // closed when a line left lines, wakes up a blocked pushRoom
+}
+
+type outboxLine struct {
+	queue  string
+	line   string
+	text   string
+	policy QueuePolicy
+}
+
+// outboxOf returns the outbox of channel, c.mu is held
+func (c *Client) outboxOf(channel string) *outbox {
+	if c.outboxes == nil {
+		c.outboxes = map[string]*outbox{}
+	}
+	ob, ok := c.outboxes[channel]
+	if !ok {
+		ob = &outbox{done: make(chan struct{}, 1), taken: make(chan struct{})}
+		c.outboxes[channel] = ob
+	}
+	return ob
+}
+
+// pushRoom queues line, the PRIVMSG of text, for channel. A line that has to wait for slow mode
+// or DuplicateDelay, or behind lines that do, goes to the outbox of the channel instead and
+// QueueError returns the reason. Only a full queue or outbox blocks, with QueueBlock.
+func (c *Client) pushRoom(ctx context.Context, channel, queue, line, text string, policy QueuePolicy) error {
+	now := c.limiter().clock.Now()
+
+	c.mu.Lock()
+	ob := c.outboxOf(channel)
+	wait, err := c.roomWait(channel, text, now)
+	if err == nil && !ob.running {
+		ob.queued++
+		c.mu.Unlock()
+		if err := c.push(queue, line, policy); err != nil {
+			c.roomDone(channel)
+			return err
+		}
+		c.rememberMessage(channel, text)
+		return nil
+	}
+
+	if policy == QueueError {
+		c.mu.Unlock()
+		if err == nil { // others wait before it
+			atomic.AddUint64(c.queueDropped[queue], 1)
+			return ErrQueueFull
+		}
+		return err
+	}
+	for len(ob.lines) >= c.queueSize() {
+		switch policy {
+		case QueueDropNewest:
+			c.mu.Unlock()
+			c.outboxDropped(outboxLine{queue: queue, line: line})
+			return nil
+		case QueueDropOldest:
+			oldest := ob.lines[0]
+			ob.lines = ob.lines[1:]
+			c.mu.Unlock()
+			c.outboxDropped(oldest)
+		default:
+			taken := ob.taken
+			c.mu.Unlock()
+			select {
+			case <-taken:
+			case <-c.context.Done():
+				return ErrClosed
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		c.mu.Lock()
+	}
+	ob.lines = append(ob.lines, outboxLine{queue: queue, line: line, text: text, policy: policy})
+	if !ob.running {
+		ob.running = true
+		go c.drainOutbox(channel, ob)
+	}
+	c.mu.Unlock()
+
+	if c.Debug && wait > 0 && errors.Is(err, ErrSlowMode) {
+		log.Printf("#%s is in slow mode, the message waits %v\n", channel, wait)
+	}
+	return nil
+}
+
+// drainOutbox queues the lines of the outbox of channel one after the other,
+// slow mode and DuplicateDelay are checked again right before every push
+func (c *Client) drainOutbox(channel string, ob *outbox) {
+	clock := c.limiter().clock
+	for {
+		c.mu.Lock()
+		if len(ob.lines) == 0 {
+			ob.running = false
+			c.mu.Unlock()
+			return
+		}
+		next := ob.lines[0]
+		wait, err := c.roomWait(channel, next.text, clock.Now())
+		if err == nil {
+			ob.lines = ob.lines[1:]
+			ob.queued++
+			close(ob.taken)
+			ob.taken = make(chan struct{})
+		}
+		c.mu.Unlock()
+
+		if err == nil {
+			if err := c.push(next.queue, next.line, next.policy); err != nil {
+				log.Println(next.line+":", err)
+				c.privmsgDone(nil, next.line, err)
+				continue
+			}
+			c.rememberMessage(channel, next.text)
+			continue
+		}
+
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = clock.After(wait)
+		}
+		select {
+		case <-timer:
+		case <-ob.done:
+		case <-c.context.Done():
+			return
+		}
+	}
+}
+
+// outboxDropped counts a line that QueueDropNewest or QueueDropOldest dropped from an outbox
+func (c *Client) outboxDropped(dropped outboxLine) {
+	atomic.AddUint64(c.queueDropped[dropped.queue], 1)
+	if channel, _, nonce, ok := privmsgTarget(dropped.line); ok {
+		c.sendFailed(channel, nonce, ErrQueueFull)
+	}
+}
+
+// roomWait returns why a PRIVMSG of text can't be queued for channel now and how long it waits
+// at least, 0 is until the PRIVMSG before it was written. c.mu is held.
+func (c *Client) roomWait(channel, text string, now time.Time) (time.Duration, error) {
+	var queued int
+	if ob, ok := c.outboxes[channel]; ok {
+		queued = ob.queued
+	}
+
+	if room, ok := c.rooms[channel]; ok && room.Slow > 0 && !c.moderator[channel] && !c.badges[channel].IsVIP() {
+		if queued > 0 { // its interval starts once it is written
+			return 0, slowModeError(channel)
+		}
+		if until := c.slowUntil[channel]; until.After(now) {
+			return until.Sub(now), slowModeError(channel)
+		}
+	}
+
+	if last, ok := c.lastMessage[channel]; ok && last.text == text && c.delaysDuplicate(text) {
+		if queued > 0 {
+			return 0, duplicateError(channel)
+		}
+		if end := last.at.Add(DuplicateWindow); end.After(now) {
+			return end.Sub(now), duplicateError(channel)
+		}
+	}
+	return 0, nil
+}
+
+// privmsgDone is called for every PRIVMSG line that left the chat queues, err is nil if it was written to conn
+func (c *Client) privmsgDone(conn Transport, line string, err error) {
+	channel, text, nonce, ok := privmsgTarget(line)
+	if !ok {
+		return
+	}
+	if err == nil {
+		c.messageWritten(channel, text)
+		c.bookSlowMode(channel)
+		c.sendWritten(conn, channel, nonce)
+	} else {
+		c.sendFailed(channel, nonce, err)
+	}
+	c.roomDone(channel)
+}
+
+// roomDone counts a PRIVMSG of channel that left the chat queues and wakes up drainOutbox
+func (c *Client) roomDone(channel string) {
+	c.mu.Lock()
+	ob, ok := c.outboxes[channel]
+	if ok && ob.queued > 0 {
+		ob.queued--
+	}
+	c.mu.Unlock()
+
+	if ok {
+		select {
+		case ob.done <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// privmsgTarget returns the channel (without #), the text and the client-nonce of a PRIVMSG line
+func privmsgTarget(line string) (string, string, string, bool) {
+	msg, err := parseIRCMessageOptions([]byte(line), false)
+	if err != nil || string(msg.Command) != "PRIVMSG" || len(msg.Params) < 2 {
+		return "", "", "", false
+	}
+	channel := strings.ToLower(strings.TrimPrefix(string(msg.Params[0]), "#"))
+	return channel, string(msg.Params[1]), string(msg.Tags["client-nonce"]), true
+}
@@ -0,0 +1,98 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrivateMessage is a typed PRIVMSG https://dev.twitch.tv/docs/irc/tags#privmsg-twitch-tags
+type PrivateMessage struct {
+	Channel     string // without #
+	UserLogin   string
+	UserID      string
+	DisplayName string
+	Text        string
+	ID          string
+	RoomID      string
+	Time        time.Time
+	Badges      Badges
+	BadgeInfo   Badges
+	Color       string
+	Bits        int
+	Emotes      []Emote
+	FirstMsg    bool
+	Action      bool         // sent with /me, Text is without the ACTION framing
+	ReplyParent *ReplyParent // nil if the message is not a reply
+
+	IRCMessage IRCMessage
+}
+
+// ReplyParent is the message a PrivateMessage replies to
+type ReplyParent struct {
+	MsgID       string
+	MsgBody     string
+	UserID      string
+	UserLogin   string
+	DisplayName string
+}
+
+// ParsePrivateMessage converts a PRIVMSG IRCMessage, missing tags are left empty
+func ParsePrivateMessage(msg IRCMessage) PrivateMessage {
+	pm := PrivateMessage{
+		UserLogin:   msg.Source().Nick,
+		UserID:      string(msg.Tags["user-id"]),
+		DisplayName: string(msg.Tags["display-name"]),
+		ID:          string(msg.Tags["id"]),
+		RoomID:      string(msg.Tags["room-id"]),
+		Time:        parseTmiSentTs(msg.Tags["tmi-sent-ts"]),
+		Badges:      msg.Badges(),
+		BadgeInfo:   msg.BadgeInfo(),
+		Color:       string(msg.Tags["color"]),
+		FirstMsg:    string(msg.Tags["first-msg"]) == "1",
+		IRCMessage:  msg,
+	}
+
+	if len(msg.Params) > 0 {
+		pm.Channel = strings.TrimPrefix(string(msg.Params[0]), "#")
+	}
+	if len(msg.Params) > 1 {
+		text := msg.Params[1]
+		// "\x01ACTION text\x01"
+		if bytes.HasPrefix(text, []byte{1, 65, 67, 84, 73, 79, 78, 32}) {
+			pm.Action = true
+			text = bytes.TrimSuffix(text[8:], []byte{1})
+		}
+		pm.Text = string(text)
+	}
+
+	if bits, ok := msg.Tags["bits"]; ok {
+		pm.Bits, _ = strconv.Atoi(string(bits))
+	}
+
+	pm.Emotes = parseEmotesTag(msg.Tags["emotes"], pm.Text)
+
+	if parentID, ok := msg.Tags["reply-parent-msg-id"]; ok {
+		pm.ReplyParent = &ReplyParent{
+			MsgID:       string(parentID),
+			MsgBody:     string(msg.Tags["reply-parent-msg-body"]),
+			UserID:      string(msg.Tags["reply-parent-user-id"]),
+			UserLogin:   string(msg.Tags["reply-parent-user-login"]),
+			DisplayName: string(msg.Tags["reply-parent-display-name"]),
+		}
+	}
+
+	return pm
+}
+
+// parseTmiSentTs converts the unix milliseconds of tmi-sent-ts
+func parseTmiSentTs(value []byte) time.Time {
+	ms, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
@@ -0,0 +1,66 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, line string) IRCMessage {
+	t.Helper()
+	msg, err := parseIRCMessage([]byte(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *msg
+}
+
+func TestParsePrivateMessage(t *testing.T) {
+	pm := ParsePrivateMessage(mustParse(t, `@badge-info=subscriber/1;badges=subscriber/0,bits/1;color=;display-name=quakerider;emote-only=1;emotes=425618:0-2,4-6;flags=;id=de8c1be1-ebf4-4c7a-9389-db858ea77611;mod=0;room-id=106159308;subscriber=1;tmi-sent-ts=1601973767402;turbo=0;user-id=165494461;user-type= :quakerider!quakerider@quakerider.tmi.twitch.tv PRIVMSG #gronkhtv :LUL LUL`))
+
+	if pm.Channel != "gronkhtv" || pm.UserLogin != "quakerider" || pm.DisplayName != "quakerider" || pm.UserID != "165494461" {
+		t.Errorf("user/channel = %q %q %q %q", pm.Channel, pm.UserLogin, pm.DisplayName, pm.UserID)
+	}
+	if pm.Text != "LUL LUL" || pm.ID != "de8c1be1-ebf4-4c7a-9389-db858ea77611" || pm.RoomID != "106159308" {
+		t.Errorf("text/id/room = %q %q %q", pm.Text, pm.ID, pm.RoomID)
+	}
+	if !pm.Time.Equal(time.Unix(1601973767, 402*int64(time.Millisecond))) {
+		t.Errorf("time = %v", pm.Time)
+	}
+	if pm.Badges["subscriber"] != "0" || pm.Badges["bits"] != "1" || pm.BadgeInfo["subscriber"] != "1" {
+		t.Errorf("badges = %v badge-info = %v", pm.Badges, pm.BadgeInfo)
+	}
+	if len(pm.Emotes) != 2 || pm.Emotes[0] != (Emote{ID: "425618", Name: "LUL", Start: 0, End: 2}) || pm.Emotes[1].Start != 4 {
+		t.Errorf("emotes = %+v", pm.Emotes)
+	}
+	if pm.Action || pm.FirstMsg || pm.ReplyParent != nil || pm.Bits != 0 {
+		t.Errorf("unexpected flags %+v", pm)
+	}
+}
+
+func TestParsePrivateMessageReply(t *testing.T) {
+	pm := ParsePrivateMessage(mustParse(t, `@badge-info=;badges=;client-nonce=ddbdc6d0813c8b4511bf04c634ba50cb;color=;display-name=ansab_;emotes=;flags=;id=42dec205-ffec-4d8e-a325-67be7679a158;mod=0;reply-parent-display-name=ansab_;reply-parent-msg-body=пацаны\sа\sНАВИ\sуже\sв\sфинале?;reply-parent-msg-id=4c735cb3-67cd-47ac-b6b3-07d112acb330;reply-parent-user-id=448938006;reply-parent-user-login=ansab_;room-id=72977645;subscriber=0;tmi-sent-ts=1601973849934;turbo=0;user-id=448938006;user-type= :ansab_!ansab_@ansab_.tmi.twitch.tv PRIVMSG #riotgamesru :@ansab_ ок, понял`))
+
+	want := ReplyParent{
+		MsgID:       "4c735cb3-67cd-47ac-b6b3-07d112acb330",
+		MsgBody:     "пацаны а НАВИ уже в финале?",
+		UserID:      "448938006",
+		UserLogin:   "ansab_",
+		DisplayName: "ansab_",
+	}
+	if pm.ReplyParent == nil || *pm.ReplyParent != want {
+		t.Errorf("reply parent = %+v", pm.ReplyParent)
+	}
+}
+
+func TestParsePrivateMessageActionBits(t *testing.T) {
+	pm := ParsePrivateMessage(mustParse(t, "@bits=100;emotes=;first-msg=1 :spddl!spddl@spddl.tmi.twitch.tv PRIVMSG #spddl :\x01ACTION cheer100 hi\x01"))
+
+	if !pm.Action || pm.Text != "cheer100 hi" {
+		t.Errorf("action = %v text = %q", pm.Action, pm.Text)
+	}
+	if pm.Bits != 100 || !pm.FirstMsg {
+		t.Errorf("bits = %d first-msg = %v", pm.Bits, pm.FirstMsg)
+	}
+}
@@ -0,0 +1,170 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// QueuePolicy decides what Say, Whisper and Join do when their queue is full
+type QueuePolicy int
+
+const (
+	QueueBlock      QueuePolicy = iota // wait for room
+	QueueDropNewest                    // drop the new line
+	QueueDropOldest                    // drop the oldest waiting line to make room
+	QueueError                         // return ErrQueueFull
+)
+
+// DefaultQueueSize is the capacity of the outbound queues if Client.QueueSize is 0
+const DefaultQueueSize = 100
+
+// ErrQueueFull is returned by TrySay and by Say, Whisper and Join with QueueError
+var ErrQueueFull = errors.New("twitch: outbound queue is full")
+
+// QueueStats is a snapshot of one outbound queue
+type QueueStats struct {
+	Depth    int // lines waiting for the rate limiter
+	Capacity int
+	Dropped  uint64 // by QueueDropNewest, QueueDropOldest and QueueError
+}
+
+// the names of QueueStats
+const (
+	queueAuthenticate        = "authenticate"
+	queueJoin                = "join"
+	queueChat                = "chat"
+	queueChatModOp           = "chatModOp"
+	queueWhisper             = "whisper"
+	queueWhisperKnownBot     = "whisperKnownBot"
+	queueWhisperVerifiedBots = "whisperVerifiedBots"
+)
+
+func (q *EmitQueue) byName() map[string]chan string {
+	return map[string]chan string{
+		queueAuthenticate:        q.Authenticate,
+		queueJoin:                q.Join,
+		queueChat:                q.RateLimit,
+		queueChatModOp:           q.ModOp,
+		queueWhisper:             q.Whisper,
+		queueWhisperKnownBot:     q.WhisperKnownBot,
+		queueWhisperVerifiedBots: q.WhisperVerifiedBots,
+	}
+}
+
+// QueueStats returns the outbound queues by name: authenticate, join, chat, chatModOp,
+// whisper, whisperKnownBot and whisperVerifiedBots
+func (c *Client) QueueStats() map[string]QueueStats {
+	c.queueMu.RLock()
+	defer c.queueMu.RUnlock()
+
+	stats := map[string]QueueStats{}
+	for name, queue := range c.queues {
+		stats[name] = QueueStats{
+			Depth:    len(queue),
+			Capacity: cap(queue),
+			Dropped:  atomic.LoadUint64(c.queueDropped[name]),
+		}
+	}
+	return stats
+}
+
+// queueSize is the capacity of the queues and of the outbox of every channel
+func (c *Client) queueSize() int {
+	if c.QueueSize <= 0 {
+		return DefaultQueueSize
+	}
+	return c.QueueSize
+}
+
+// newQueues makes the buffered queues, called by NewClient
+func (c *Client) newQueues() {
+	size := c.queueSize()
+	c.emitQueue = EmitQueue{
+		Authenticate:        make(chan string, size),
+		Join:                make(chan string, size),
+		RateLimit:           make(chan string, size),
+		ModOp:               make(chan string, size),
+		Whisper:             make(chan string, size),
+		WhisperKnownBot:     make(chan string, size),
+		WhisperVerifiedBots: make(chan string, size),
+	}
+	c.queues = c.emitQueue.byName()
+	c.queueDropped = map[string]*uint64{}
+	for name := range c.queues {
+		c.queueDropped[name] = new(uint64)
+	}
+}
+
+// closeQueues ends the send loops, push returns ErrClosed afterwards
+func (c *Client) closeQueues() {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	if c.queuesClosed {
+		return
+	}
+	c.queuesClosed = true
+	for _, queue := range c.queues {
+		close(queue)
+	}
+}
+
+// push adds line to the queue name according to policy
+func (c *Client) push(name, line string, policy QueuePolicy) error {
+	c.queueMu.RLock()
+	defer c.queueMu.RUnlock() // Close cancels the context first, a blocked push returns
+	if c.queuesClosed {
+		return ErrClosed
+	}
+	queue := c.queues[name]
+
+	select {
+	case queue <- line:
+		return nil
+	default:
+	}
+
+	switch policy {
+	case QueueDropNewest:
+		c.lineDropped(name, line)
+		return nil
+
+	case QueueDropOldest:
+		for {
+			select {
+			case old := <-queue:
+				c.lineDropped(name, old)
+			default: // the send loop took one
+			}
+			select {
+			case queue <- line:
+				return nil
+			default:
+			}
+		}
+
+	case QueueError:
+		atomic.AddUint64(c.queueDropped[name], 1)
+		return ErrQueueFull
+
+	default:
+		select {
+		case queue <- line:
+			return nil
+		case <-c.context.Done():
+			return ErrClosed
+		}
+	}
+}
+
+// lineDropped counts a line dropped by QueueDropNewest or QueueDropOldest
+func (c *Client) lineDropped(name, line string) {
+	atomic.AddUint64(c.queueDropped[name], 1)
+	switch name {
+	case queueJoin:
+		c.joinSent(line) // the next Join or rejoin queues it again
+	case queueChat, queueChatModOp:
+		c.privmsgDone(nil, line, ErrQueueFull)
+	}
+}
@@ -0,0 +1,154 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newQueueClient has queues of size 2 without the send loops
+func newQueueClient(policy QueuePolicy) *Client {
+	c := &Client{QueueSize: 2, QueuePolicy: policy}
+	c.context, c.cancel = context.WithCancel(context.Background())
+	c.newQueues()
+	return c
+}
+
+func drain(queue chan string) []string {
+	var lines []string
+	for len(queue) != 0 {
+		lines = append(lines, <-queue)
+	}
+	return lines
+}
+
+func TestQueuePolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy  QueuePolicy
+		err     error
+		waiting []string
+	}{
+		{QueueDropNewest, nil, []string{"1", "2"}},
+		{QueueDropOldest, nil, []string{"2", "3"}},
+		{QueueError, ErrQueueFull, []string{"1", "2"}},
+	} {
+		c := newQueueClient(tt.policy)
+		var err error
+		for _, msg := range []string{"1", "2", "3"} {
+			err = c.Say("spddl", msg, false)
+		}
+		if err != tt.err {
+			t.Errorf("policy %d: Say = %v, want %v", tt.policy, err, tt.err)
+		}
+
+		stats := c.QueueStats()[queueChat]
+		if stats != (QueueStats{Depth: 2, Capacity: 2, Dropped: 1}) {
+			t.Errorf("policy %d: %+v", tt.policy, stats)
+		}
+
+		var waiting []string
+		for _, line := range drain(c.emitQueue.RateLimit) {
+			waiting = append(waiting, line[len(line)-1:])
+		}
+		if !reflect.DeepEqual(waiting, tt.waiting) {
+			t.Errorf("policy %d: waiting %v, want %v", tt.policy, waiting, tt.waiting)
+		}
+	}
+}
+
+func TestTrySay(t *testing.T) {
+	c := newQueueClient(QueueBlock)
+	for i := 0; i < 2; i++ {
+		if err := c.TrySay("spddl", "hi", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.TrySay("spddl", "hi", true); err != ErrQueueFull {
+		t.Errorf("TrySay = %v", err)
+	}
+	if err := c.TrySay("spddl", "hi", false); err != nil {
+		t.Errorf("the chat queue is full as well: %v", err)
+	}
+}
+
+func TestQueueBlock(t *testing.T) {
+	c := newQueueClient(QueueBlock)
+	c.Say("spddl", "1", false)
+	c.Say("spddl", "2", false)
+
+	done := make(chan error)
+	go func() {
+		done <- c.Say("spddl", "3", false)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Say did not block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	<-c.emitQueue.RateLimit
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		done <- c.Say("spddl", "4", false)
+	}()
+	c.cancel()
+	if err := <-done; err != ErrClosed {
+		t.Errorf("blocked Say after Close = %v", err)
+	}
+
+	c.closeQueues()
+	if err := c.Say("spddl", "5", false); err != ErrClosed {
+		t.Errorf("Say after Close = %v", err)
+	}
+}
+
+func TestRejoinFullQueue(t *testing.T) {
+	c := newQueueClient(QueueBlock)
+	c.User = "justinfan1234"
+	c.Channel = []string{"a", "b", "c"}
+	c.resetJoined()
+	c.emitQueue.Join <- "x"
+	c.emitQueue.Join <- "y"
+
+	done := make(chan struct{})
+	go func() {
+		c.rejoin() // on the read loop
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("rejoin blocks on a full join queue")
+	}
+	if dropped := c.QueueStats()[queueJoin].Dropped; dropped != 3 {
+		t.Errorf("%d JOINs dropped, want 3", dropped)
+	}
+}
+
+func TestJoinQueuePolicy(t *testing.T) {
+	c := newQueueClient(QueueError)
+	c.User = "justinfan1234"
+	c.resetJoined()
+	c.emitQueue.Join <- "x"
+	c.emitQueue.Join <- "y"
+	if err := c.Join([]string{"a"}); err != ErrQueueFull {
+		t.Errorf("Join = %v, want ErrQueueFull", err)
+	}
+
+	c = newQueueClient(QueueDropOldest)
+	c.User = "justinfan1234"
+	c.resetJoined()
+	c.Join([]string{"a", "b", "c"})
+	if got, want := drain(c.emitQueue.Join), []string{":justinfan1234! JOIN #b", ":justinfan1234! JOIN #c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("join queue = %v, want %v", got, want)
+	}
+	c.Join([]string{"a"}) // the dropped JOIN is no longer pending
+	if got, want := drain(c.emitQueue.Join), []string{":justinfan1234! JOIN #a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("join queue = %v, want %v", got, want)
+	}
+}
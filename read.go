@@ -6,32 +6,25 @@ import (
 	"bytes"
 )
 
-func (c *Client) read() {
+// read runs for the lifetime of conn, a retired conn (see handover) ends without a reconnect
+func (c *Client) read(conn Transport) {
 	for {
 		select {
 		case <-c.context.Done():
 			c.Close()
 			return
 		default:
-			if c.IsConnected() {
-				_, r, err := c.conn.Reader(c.context)
-				if err != nil {
-					c.CloseAndReconnect()
-					continue
-				}
-
-				var buf bytes.Buffer
-				buf.Grow(bytes.MinRead)
-
-				_, err = buf.ReadFrom(r)
-				if err != nil {
-					continue // failed to read: WebSocket closed: sent close frame: status = StatusNormalClosure and reason = ""
+			frame, err := conn.ReadFrame(c.context)
+			if err != nil {
+				if c.getConn() == conn {
+					c.reconnectAfter(err)
 				}
+				return
+			}
 
-				msg := bytes.Split(buf.Bytes(), []byte{13, 10}) // "\r\n"
-				for _, value := range msg {
-					c.parser(value)
-				}
+			msg := bytes.Split(frame, []byte{13, 10}) // "\r\n"
+			for _, value := range msg {
+				c.parse(conn, value)
 			}
 		}
 	}
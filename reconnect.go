@@ -0,0 +1,75 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ReconnectPolicy decides how long the client waits before it dials again
+type ReconnectPolicy interface {
+	// NextDelay is called before every reconnect attempt (starting at 1),
+	// ok == false stops reconnecting
+	NextDelay(attempt int) (delay time.Duration, ok bool)
+}
+
+// ExponentialBackoff doubles the delay up to Max and waits a random time between 0 and that delay (full jitter)
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+type ExponentialBackoff struct {
+	Initial     time.Duration // 0 is 1s
+	Max         time.Duration // 0 doesn't cap the delay
+	MaxAttempts int           // 0 retries forever
+}
+
+func (b ExponentialBackoff) NextDelay(attempt int) (time.Duration, bool) {
+	if b.MaxAttempts > 0 && attempt > b.MaxAttempts {
+		return 0, false
+	}
+
+	delay := b.Initial
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for i := 1; i < attempt && (b.Max <= 0 || delay < b.Max) && delay < math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+	return time.Duration(rand.Int63n(int64(delay))) + 1, true
+}
+
+// ConstantBackoff waits Delay before every attempt
+type ConstantBackoff struct {
+	Delay       time.Duration
+	MaxAttempts int // 0 retries forever
+}
+
+func (b ConstantBackoff) NextDelay(attempt int) (time.Duration, bool) {
+	if b.MaxAttempts > 0 && attempt > b.MaxAttempts {
+		return 0, false
+	}
+	return b.Delay, true
+}
+
+// NoRetry doesn't reconnect at all
+type NoRetry struct{}
+
+func (NoRetry) NextDelay(attempt int) (time.Duration, bool) {
+	return 0, false
+}
+
+// defaultReconnectPolicy is used if Client.ReconnectPolicy is nil
+var defaultReconnectPolicy = ExponentialBackoff{
+	Initial: time.Second,
+	Max:     600 * time.Second, // 10 min
+}
+
+func (c *Client) reconnectPolicy() ReconnectPolicy {
+	if c.ReconnectPolicy == nil {
+		return defaultReconnectPolicy
+	}
+	return c.ReconnectPolicy
+}
@@ -0,0 +1,133 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{Initial: time.Second, Max: 8 * time.Second, MaxAttempts: 6}
+	for attempt, max := range []time.Duration{0, 1, 2, 4, 8, 8, 8} {
+		if attempt == 0 {
+			continue
+		}
+		for i := 0; i < 100; i++ {
+			delay, ok := b.NextDelay(attempt)
+			if !ok || delay < 0 || delay > max*time.Second {
+				t.Fatalf("NextDelay(%d) = %v, %v, want <= %v", attempt, delay, ok, max*time.Second)
+			}
+		}
+	}
+	if _, ok := b.NextDelay(7); ok {
+		t.Error("NextDelay after MaxAttempts")
+	}
+}
+
+func TestExponentialBackoffZeroValue(t *testing.T) {
+	var b ExponentialBackoff // no cap, starts at 1s
+	for attempt := 1; attempt < 100; attempt++ {
+		delay, ok := b.NextDelay(attempt)
+		if !ok || delay <= 0 {
+			t.Fatalf("NextDelay(%d) = %v, %v, want a pause", attempt, delay, ok)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if delay, _ := b.NextDelay(5); delay > 16*time.Second {
+			t.Fatalf("NextDelay(5) = %v, want <= 16s", delay)
+		}
+	}
+}
+
+func TestConstantBackoffAndNoRetry(t *testing.T) {
+	b := ConstantBackoff{Delay: time.Second, MaxAttempts: 2}
+	if delay, ok := b.NextDelay(2); !ok || delay != time.Second {
+		t.Errorf("NextDelay(2) = %v, %v", delay, ok)
+	}
+	if _, ok := b.NextDelay(3); ok {
+		t.Error("NextDelay after MaxAttempts")
+	}
+	if _, ok := (NoRetry{}).NextDelay(1); ok {
+		t.Error("NoRetry retries")
+	}
+}
+
+func TestReconnectCallbacks(t *testing.T) {
+	var connections int32
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		switch atomic.AddInt32(&connections, 1) {
+		case 1: // drop the first session right after the login
+			if _, err := conn.readUntil("NICK"); err != nil {
+				return
+			}
+			_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+		default:
+			welcome(conn)
+		}
+	})
+
+	c := newTestClient(t, tmi.URL)
+	c.ReconnectPolicy = ConstantBackoff{Delay: 10 * time.Millisecond}
+
+	var mu sync.Mutex
+	var attempts []int
+	c.OnReconnecting = func(attempt int, delay time.Duration, err error) {
+		mu.Lock()
+		attempts = append(attempts, attempt)
+		mu.Unlock()
+		if delay != 10*time.Millisecond || err == nil {
+			t.Errorf("OnReconnecting(%d, %v, %v)", attempt, delay, err)
+		}
+	}
+	reconnected := make(chan struct{}, 1)
+	c.OnReconnected = func() {
+		reconnected <- struct{}{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-reconnected:
+	case <-ctx.Done():
+		t.Fatal("OnReconnected was not called")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts) != 1 || attempts[0] != 1 {
+		t.Errorf("attempts = %v, want [1]", attempts)
+	}
+}
+
+func TestReconnectNoRetry(t *testing.T) {
+	var connections int32
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		atomic.AddInt32(&connections, 1)
+		if _, err := conn.readUntil("NICK"); err != nil {
+			return
+		}
+		_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+	})
+
+	c := newTestClient(t, tmi.URL)
+	c.ReconnectPolicy = NoRetry{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&connections); n != 1 {
+		t.Errorf("%d connections, want 1", n)
+	}
+}
@@ -7,26 +7,43 @@
 package twitch
 
 import (
+	"errors"
 	"log"
 	"time"
-
-	"nhooyr.io/websocket"
 )
 
 // CloseAndReconnect will try to reconnect.
 func (c *Client) CloseAndReconnect() {
+	c.reconnectAfter(errReconnectRequested)
+}
+
+var errReconnectRequested = errors.New("twitch: CloseAndReconnect was called")
+var errPongTimeout = errors.New("twitch: no pong message was received within the pong timeout")
+
+// reconnectAfter closes the connection and starts connect, err is the reason for OnReconnecting
+func (c *Client) reconnectAfter(err error) {
 	if c.getConn() != nil {
 		c.mu.Lock()
-		c.conn.Close(websocket.StatusNormalClosure, "")
+		c.conn.Close()
 		c.mu.Unlock()
 	}
 	c.mu.Lock()
-	c.isConnected = false
+	reconnect := c.reconnect && !c.connecting // not before Run/Connect, after a failed login or twice
+	if reconnect {
+		c.connecting = true
+	}
+	idle := !c.reconnect
 	c.mu.Unlock()
-	go c.connect()
+
+	if reconnect {
+		c.setState(Reconnecting)
+		go c.connect(err)
+	} else if idle {
+		c.setState(Disconnected)
+	}
 }
 
-func (c *Client) getConn() *websocket.Conn {
+func (c *Client) getConn() Transport {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -36,102 +53,104 @@ func (c *Client) getConn() *websocket.Conn {
 // Close closes the underlying network connection without
 // sending or waiting for a close frame.
 func (c *Client) Close() {
-	if c.getConn() != nil {
-		c.mu.Lock()
-		c.cancel()
-		c.conn.Close(websocket.StatusNormalClosure, "")
-		c.mu.Unlock()
-	}
-
+	c.setState(Closing)
+	c.cancel()
 	c.mu.Lock()
-	select {
-	case <-c.emitQueue.Authenticate:
-	default:
-		close(c.emitQueue.Authenticate)
+	if c.conn != nil {
+		c.conn.Close()
 	}
-
-	select {
-	case <-c.emitQueue.Join:
-	default:
-		close(c.emitQueue.Join)
-	}
-
-	select {
-	case <-c.emitQueue.RateLimit:
-	default:
-		close(c.emitQueue.RateLimit)
+	if c.handoverConn != nil {
+		c.handoverConn.Close()
 	}
-
-	select {
-	case <-c.emitQueue.ModOp:
-	default:
-		close(c.emitQueue.ModOp)
-	}
-
-	select {
-	case <-c.emitQueue.Whisper:
-	default:
-		close(c.emitQueue.Whisper)
-	}
-
-	c.isConnected = false
 	c.mu.Unlock()
+
+	c.closeQueues()
+	c.setState(Closed)
 }
 
 func (c *Client) Run() {
+	c.mu.Lock()
+	c.reconnect = true
+	c.connecting = true
+	c.mu.Unlock()
+
 	// Connect
-	go c.connect()
+	go c.connect(nil)
 
 	// wait on first attempt
 	time.Sleep(2 * time.Second)
 }
 
-func (c *Client) connect() {
-	delay := 0
-	for {
+// connect dials until it succeeds or the ReconnectPolicy gives up.
+// cause is the error that dropped the last connection, nil for the first connection of Run.
+func (c *Client) connect(cause error) {
+	defer func() {
+		c.mu.Lock()
+		c.connecting = false
+		c.mu.Unlock()
+	}()
+
+	policy := c.reconnectPolicy()
+	attempt := 0
+	if cause != nil {
+		attempt = 1
+		c.mu.RLock()
+		if c.authFailures > attempt { // rejected logins count as failed attempts
+			attempt = c.authFailures
+		}
+		c.mu.RUnlock()
+	}
+
+	for ; ; attempt++ {
+		if attempt != 0 { // the first connection is dialed right away
+			delay, ok := policy.NextDelay(attempt)
+			if !ok {
+				log.Printf("Reconnect: giving up after %d attempts: %v\n", attempt-1, cause)
+				c.mu.Lock()
+				c.reconnect = false
+				c.mu.Unlock()
+				c.setState(Disconnected)
+				return
+			}
+
+			if c.OnReconnecting != nil {
+				c.OnReconnecting(attempt, delay, cause)
+			}
+			if c.Debug {
+				log.Println("Reconnect: will try again in", delay)
+			}
+
+			select {
+			case <-c.context.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+
 		select {
 		case <-c.context.Done():
 			return
 		default:
-			wsConn, _, err := websocket.Dial(c.context, c.Server, nil)
-			c.mu.Lock()
-			c.conn = wsConn
-			c.isConnected = err == nil
-			c.mu.Unlock()
-
-			if err != nil {
-				log.Println(err)
-			} else {
-				if c.Debug {
-					log.Printf("Connection was successfully established with %s\n", c.Server)
-				}
-				c.OnConnect = func(status bool) {
-					c.joinCommand(c.Channel)
-				}
-				c.login()
-				return
-			}
+		}
 
-			if delay != 0 {
-				if c.Debug {
-					log.Println("Reconnect: will try again in", delay, "seconds.")
-				}
-				time.Sleep(time.Duration(delay) * time.Second)
-				delay *= 2
-				if delay > 600 { // 10 min
-					delay = 600
-				}
-			} else {
-				delay = 1
+		c.setState(Dialing)
+		err := c.dial(c.context)
+		if err == nil {
+			if attempt != 0 {
+				c.mu.Lock()
+				c.reconnected = true // OnReconnected is called on RPL_WELCOME
+				c.mu.Unlock()
 			}
+			c.login()
+			return
 		}
+		log.Println("Reconnect:", err)
+		c.setState(Reconnecting)
+		cause = err
 	}
 }
 
-// IsConnected returns the WebSocket connection state
+// IsConnected is true in the Ready state, after tmi welcomed the client
 func (c *Client) IsConnected() bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	return c.isConnected
+	return c.State() == Ready
 }
@@ -0,0 +1,116 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RoomState of a channel, merged from the ROOMSTATE after the JOIN and the partial updates later on
+type RoomState struct {
+	Channel       string
+	RoomID        string
+	EmoteOnly     bool
+	FollowersOnly int // minutes a user must follow, -1 is off
+	R9K           bool
+	Slow          int // seconds between the messages of a user
+	SubsOnly      bool
+}
+
+// RoomState returns the state of channel (without #), false before its ROOMSTATE arrived
+func (c *Client) RoomState(channel string) (RoomState, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	room, ok := c.rooms[strings.ToLower(strings.TrimPrefix(channel, "#"))]
+	if !ok {
+		return RoomState{}, false
+	}
+	return *room, true
+}
+
+// mergeRoomState only changes the fields whose tags are in msg
+func (c *Client) mergeRoomState(channel string, msg IRCMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rooms == nil {
+		c.rooms = map[string]*RoomState{}
+	}
+	room, ok := c.rooms[channel]
+	if !ok {
+		room = &RoomState{Channel: channel, FollowersOnly: -1}
+		c.rooms[channel] = room
+	}
+
+	for key, value := range msg.Tags {
+		switch key {
+		case "room-id":
+			room.RoomID = string(value)
+		case "emote-only":
+			room.EmoteOnly = string(value) == "1"
+		case "followers-only":
+			if n, err := strconv.Atoi(string(value)); err == nil {
+				room.FollowersOnly = n
+			}
+		case "r9k":
+			room.R9K = string(value) == "1"
+		case "slow":
+			if n, err := strconv.Atoi(string(value)); err == nil {
+				room.Slow = n
+			}
+		case "subs-only":
+			room.SubsOnly = string(value) == "1"
+		}
+	}
+}
+
+// checkRoom returns a *SendError if the mode of the room forbids our message. Moderators and the
+// broadcaster are exempt from all modes. Emote-only is left to the msg_emoteonly NOTICE of tmi,
+// the emote sets of the account are unknown. Slow mode only delays a message (see pushRoom).
+func (c *Client) checkRoom(channel string) error {
+	now := c.limiter().clock.Now()
+
+	c.mu.RLock()
+	current, ok := c.rooms[channel]
+	var room RoomState
+	if ok {
+		room = *current
+	}
+	mod, badges := c.moderator[channel], c.badges[channel]
+	c.mu.RUnlock()
+	if !ok || mod {
+		return nil
+	}
+
+	switch {
+	case room.SubsOnly && !badges.IsSubscriber() && !badges.IsVIP():
+		return &SendError{Channel: channel, MsgID: "msg_subsonly", Notice: "This room is in subscribers only mode."}
+	case room.FollowersOnly >= 0 && c.FollowedAt != nil && !badges.IsVIP():
+		since, follows := c.FollowedAt(channel)
+		if !follows || now.Sub(since) < time.Duration(room.FollowersOnly)*time.Minute {
+			return &SendError{Channel: channel, MsgID: "msg_followersonly", Notice: "This room is in followers-only mode."}
+		}
+	}
+	return nil
+}
+
+func slowModeError(channel string) error {
+	return &SendError{Channel: channel, MsgID: "msg_slowmode", Notice: "This room is in slow mode and you are sending messages too quickly."}
+}
+
+// bookSlowMode starts the slow mode interval of channel after a message was written
+func (c *Client) bookSlowMode(channel string) {
+	now := c.limiter().clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	room, ok := c.rooms[channel]
+	if !ok || room.Slow <= 0 || c.moderator[channel] || c.badges[channel].IsVIP() {
+		return
+	}
+	if c.slowUntil == nil {
+		c.slowUntil = map[string]time.Time{}
+	}
+	c.slowUntil[channel] = now.Add(time.Duration(room.Slow) * time.Second)
+}
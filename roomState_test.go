@@ -0,0 +1,169 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMergeRoomState(t *testing.T) {
+	c := &Client{}
+	c.membershipOn(c.getConn(), mustParse(t, "@emote-only=0;followers-only=-1;r9k=0;room-id=12345;slow=0;subs-only=0 :tmi.twitch.tv ROOMSTATE #spddl"))
+	c.membershipOn(c.getConn(), mustParse(t, "@room-id=12345;slow=30 :tmi.twitch.tv ROOMSTATE #spddl"))
+	c.membershipOn(c.getConn(), mustParse(t, "@followers-only=10;room-id=12345 :tmi.twitch.tv ROOMSTATE #spddl"))
+
+	room, ok := c.RoomState("#Spddl")
+	want := RoomState{Channel: "spddl", RoomID: "12345", FollowersOnly: 10, Slow: 30}
+	if !ok || room != want {
+		t.Errorf("RoomState = %+v, %v, want %+v", room, ok, want)
+	}
+	if _, ok := c.RoomState("other"); ok {
+		t.Error("RoomState of a channel without ROOMSTATE")
+	}
+}
+
+func TestCheckRoom(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := &Client{User: "justinfan1234", Limiter: NewAccountLimiterClock(UserLimits(), clock)}
+
+	for _, tt := range []struct {
+		roomState, userState string
+		followedAt           time.Duration // ago, 0 doesn't follow
+		err                  error
+	}{
+		{"@subs-only=1", "@badges=", 0, ErrSubsOnly},
+		{"@subs-only=1", "@badges=subscriber/12", 0, nil},
+		{"@subs-only=1", "@badges=;mod=1", 0, nil},
+		{"@emote-only=1", "@badges=", 0, nil}, // left to tmi, the message may be only emotes
+		{"@followers-only=10", "@badges=", 5 * time.Minute, ErrFollowersOnly},
+		{"@followers-only=10", "@badges=", 0, ErrFollowersOnly},
+		{"@followers-only=10", "@badges=", time.Hour, nil},
+		{"@followers-only=0", "@badges=vip/1", 0, nil},
+	} {
+		c.rooms, c.moderator, c.badges = nil, nil, nil
+		c.membershipOn(c.getConn(), mustParse(t, tt.roomState+" :tmi.twitch.tv ROOMSTATE #spddl"))
+		c.membershipOn(c.getConn(), mustParse(t, tt.userState+" :tmi.twitch.tv USERSTATE #spddl"))
+		c.FollowedAt = func(channel string) (time.Time, bool) {
+			return clock.Now().Add(-tt.followedAt), tt.followedAt != 0
+		}
+
+		err := c.checkRoom("spddl")
+		if !errors.Is(err, tt.err) || (err != nil && tt.err == nil) {
+			t.Errorf("%s %s: %v, want %v", tt.roomState, tt.userState, err, tt.err)
+		}
+	}
+
+	c.FollowedAt = nil
+	c.rooms, c.moderator, c.badges = nil, nil, nil
+	c.membershipOn(c.getConn(), mustParse(t, "@followers-only=10 :tmi.twitch.tv ROOMSTATE #spddl"))
+	if err := c.checkRoom("spddl"); err != nil {
+		t.Errorf("followers-only without FollowedAt: %v", err)
+	}
+}
+
+func TestSlowMode(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := newQueueClient(QueueBlock)
+	c.Limiter = NewAccountLimiterClock(UserLimits(), clock)
+	c.membershipOn(c.getConn(), mustParse(t, "@slow=30 :tmi.twitch.tv ROOMSTATE #spddl"))
+
+	for _, msg := range []string{"1", "2"} {
+		if err := c.Say("spddl", msg, false); err != nil { // neither waits for slow mode
+			t.Fatal(err)
+		}
+	}
+	if err := c.TrySay("spddl", "3", false); !errors.Is(err, ErrSlowMode) {
+		t.Errorf("TrySay = %v, want ErrSlowMode", err)
+	}
+	if depth := len(c.emitQueue.RateLimit); depth != 1 {
+		t.Fatalf("depth %d, the second message must wait for slow mode", depth)
+	}
+
+	first := <-c.emitQueue.RateLimit
+	clock.Advance(20 * time.Second) // the rate limiter held the first one
+	c.privmsgDone(nil, first, nil)
+	waitForTimer(t, clock)
+
+	clock.Advance(29 * time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if depth := len(c.emitQueue.RateLimit); depth != 0 {
+		t.Fatal("queued 29s after the first message was written")
+	}
+	clock.Advance(time.Second)
+	var second string
+	select {
+	case second = <-c.emitQueue.RateLimit:
+		if second != ":tmi.twitch.tv PRIVMSG #spddl :2" {
+			t.Errorf("queued %q", second)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("not queued 30s after the first message was written")
+	}
+
+	c.membershipOn(c.getConn(), mustParse(t, "@badges=moderator/1;mod=1 :tmi.twitch.tv USERSTATE #spddl"))
+	if err := c.TrySay("spddl", "4", false); err != nil { // the second one is still in flight
+		t.Errorf("moderator: %v", err)
+	}
+}
+
+func TestSlowModeOtherChannels(t *testing.T) {
+	c := newQueueClient(QueueBlock)
+	c.membershipOn(c.getConn(), mustParse(t, "@slow=30 :tmi.twitch.tv ROOMSTATE #a"))
+	c.emitQueue.RateLimit <- "x"
+	c.emitQueue.RateLimit <- "y"
+
+	go c.Say("a", "blocks on the full queue", false)
+	for queued := 0; queued == 0; time.Sleep(time.Millisecond) {
+		c.mu.RLock()
+		if ob, ok := c.outboxes["a"]; ok {
+			queued = ob.queued
+		}
+		c.mu.RUnlock()
+	}
+	done := make(chan struct{})
+	go func() {
+		c.Say("a", "waits for slow mode", false)
+		c.Say("b", "hi", true)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a push that blocks on a full queue blocks other messages")
+	}
+}
+
+func TestSlowModeQueueFull(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := newQueueClient(QueueBlock)
+	c.Limiter = NewAccountLimiterClock(UserLimits(), clock)
+	c.membershipOn(c.getConn(), mustParse(t, "@slow=30 :tmi.twitch.tv ROOMSTATE #spddl"))
+	c.emitQueue.RateLimit <- "a"
+	c.emitQueue.RateLimit <- "b"
+
+	if err := c.TrySay("spddl", "hi", false); err != ErrQueueFull {
+		t.Fatalf("TrySay to a full queue in slow mode = %v", err)
+	}
+	drain(c.emitQueue.RateLimit)
+	if err := c.TrySay("spddl", "hi", false); err != nil { // the failed push left nothing in flight
+		t.Fatal(err)
+	}
+}
+
+// waitForTimer blocks until a goroutine waits on clock
+func waitForTimer(t *testing.T, clock *fakeClock) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		clock.mu.Lock()
+		n := len(clock.waiters)
+		clock.mu.Unlock()
+		if n != 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("nothing waits on the clock")
+		}
+	}
+}
@@ -0,0 +1,285 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// the errors a *SendError unwraps to, by the msg-id of the NOTICE
+var (
+	ErrRateLimited   = errors.New("twitch: message was rate limited")
+	ErrDuplicate     = errors.New("twitch: message is identical to the previous one")
+	ErrBanned        = errors.New("twitch: banned in this channel")
+	ErrSlowMode      = errors.New("twitch: channel is in slow mode")
+	ErrFollowersOnly = errors.New("twitch: channel is in followers-only mode")
+	ErrEmoteOnly     = errors.New("twitch: channel is in emote-only mode")
+	ErrSubsOnly      = errors.New("twitch: channel is in subscribers-only mode")
+)
+
+var sendNotices = map[string]error{
+	"msg_ratelimit":     ErrRateLimited,
+	"msg_duplicate":     ErrDuplicate,
+	"msg_banned":        ErrBanned,
+	"msg_slowmode":      ErrSlowMode,
+	"msg_followersonly": ErrFollowersOnly,
+	"msg_emoteonly":     ErrEmoteOnly,
+	"msg_subsonly":      ErrSubsOnly,
+}
+
+// SendError is returned by SendMessage if tmi rejects the message with a NOTICE,
+// errors.Is(err, ErrDuplicate) and the others work on it
+type SendError struct {
+	Channel string
+	MsgID   string // msg-id tag of the NOTICE, e.g. "msg_duplicate"
+	Notice  string // text of the NOTICE
+}
+
+func (e *SendError) Error() string {
+	return "twitch: #" + e.Channel + ": " + e.Notice
+}
+
+func (e *SendError) Unwrap() error {
+	return sendNotices[e.MsgID]
+}
+
+// SendResult of a message tmi accepted
+type SendResult struct {
+	Channel   string
+	Nonce     string     // client-nonce tag of the PRIVMSG
+	UserState IRCMessage // the USERSTATE echo
+}
+
+type pendingSend struct {
+	nonce  string
+	result chan sendOutcome
+}
+
+type sendOutcome struct {
+	userState IRCMessage
+	err       error
+}
+
+// SendMessage sends text to channel (without #) and waits until tmi accepted or rejected it.
+// It returns a *SendError for a rejecting NOTICE, ErrQueueFull if QueuePolicy dropped the message
+// and the error of ctx if neither arrived in time.
+func (c *Client) SendMessage(ctx context.Context, channel, text string) (*SendResult, error) {
+	channel = strings.ToLower(strings.TrimPrefix(channel, "#"))
+	text, err := c.bypassDuplicate(channel, text)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := IRCMessage{
+		Tags:    map[string][]byte{"client-nonce": []byte(nonce)},
+		Prefix:  []byte("tmi.twitch.tv"),
+		Command: []byte("PRIVMSG"),
+		Params:  [][]byte{[]byte("#" + channel), []byte(text)},
+	}
+	line, err := msg.MarshalIRC()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := &pendingSend{nonce: nonce, result: make(chan sendOutcome, 1)}
+	c.mu.Lock()
+	if c.pendingSends == nil {
+		c.pendingSends = map[string][]*pendingSend{}
+	}
+	c.pendingSends[channel] = append(c.pendingSends[channel], pending)
+	c.mu.Unlock()
+	defer c.forgetSend(channel, pending)
+
+	queue := queueChat
+	if c.Moderator(channel) {
+		queue = queueChatModOp
+	}
+	if err := c.pushRoom(ctx, channel, queue, string(line), text, c.QueuePolicy); err != nil {
+		return nil, err
+	}
+
+	select {
+	case outcome := <-pending.result:
+		if outcome.err != nil {
+			return nil, outcome.err
+		}
+		return &SendResult{Channel: channel, Nonce: nonce, UserState: outcome.userState}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.context.Done():
+		return nil, ErrClosed
+	}
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (c *Client) forgetSend(channel string, pending *pendingSend) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeSend(channel, pending)
+}
+
+// removeSend is false if pending was already resolved or forgotten, c.mu is held
+func (c *Client) removeSend(channel string, pending *pendingSend) bool {
+	sends := c.pendingSends[channel]
+	for i, p := range sends {
+		if p != pending {
+			continue
+		}
+		c.pendingSends[channel] = append(sends[:i:i], sends[i+1:]...)
+		if len(c.pendingSends[channel]) == 0 {
+			delete(c.pendingSends, channel)
+		}
+		return true
+	}
+	return false
+}
+
+// pendingByNonce returns the waiting SendMessage of nonce, c.mu is held
+func (c *Client) pendingByNonce(channel, nonce string) *pendingSend {
+	if nonce == "" {
+		return nil
+	}
+	for _, p := range c.pendingSends[channel] {
+		if p.nonce == nonce {
+			return p
+		}
+	}
+	return nil
+}
+
+// sendEchoes are the PRIVMSGs written to a channel on one connection that wait for their
+// USERSTATE or NOTICE. tmi answers them in order and without client-nonce.
+type sendEchoes struct {
+	sends  []*pendingSend // nil for a PRIVMSG of Say
+	joined bool           // our JOIN was echoed, the next USERSTATE belongs to it
+}
+
+type connChannel struct {
+	conn    Transport
+	channel string
+}
+
+// echoesOf returns the sendEchoes of channel on conn, c.mu is held
+func (c *Client) echoesOf(conn Transport, channel string) *sendEchoes {
+	if c.echoes == nil {
+		c.echoes = map[connChannel]*sendEchoes{}
+	}
+	key := connChannel{conn, channel}
+	echoes, ok := c.echoes[key]
+	if !ok {
+		echoes = &sendEchoes{}
+		c.echoes[key] = echoes
+	}
+	return echoes
+}
+
+// forgetEchoes of a retired connection, c.mu is held
+func (c *Client) forgetEchoes(conn Transport) {
+	for key := range c.echoes {
+		if key.conn == conn {
+			delete(c.echoes, key)
+		}
+	}
+}
+
+// sendWritten lines up a PRIVMSG written to conn for the next USERSTATE or NOTICE of channel
+func (c *Client) sendWritten(conn Transport, channel, nonce string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	echoes := c.echoesOf(conn, channel)
+	echoes.sends = append(echoes.sends, c.pendingByNonce(channel, nonce))
+	if len(echoes.sends) > c.queueSize() { // answers went missing
+		echoes.sends = echoes.sends[1:]
+	}
+}
+
+// sendFailed resolves the SendMessage of a PRIVMSG that was dropped or couldn't be written
+func (c *Client) sendFailed(channel, nonce string, err error) {
+	c.mu.Lock()
+	pending := c.pendingByNonce(channel, nonce)
+	if pending == nil || !c.removeSend(channel, pending) {
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	pending.result <- sendOutcome{err: err}
+}
+
+// joinEchoed is called for our own JOIN on conn, tmi follows it with a USERSTATE that answers no PRIVMSG
+func (c *Client) joinEchoed(conn Transport, channel string) {
+	c.mu.Lock()
+	c.echoesOf(conn, channel).joined = true
+	c.mu.Unlock()
+}
+
+// resolveSend hands the USERSTATE echo or a rejecting NOTICE read from conn to the waiting
+// SendMessage. A client-nonce picks it, else the oldest PRIVMSG written to the channel on conn.
+func (c *Client) resolveSend(conn Transport, msg IRCMessage) {
+	if len(msg.Params) == 0 {
+		return
+	}
+	channel := strings.ToLower(strings.TrimPrefix(string(msg.Params[0]), "#"))
+
+	var outcome sendOutcome
+	switch string(msg.Command) {
+	case "USERSTATE":
+		outcome.userState = msg
+	case "NOTICE":
+		msgID := string(msg.Tags["msg-id"])
+		if sendNotices[msgID] == nil {
+			return
+		}
+		var notice string
+		if len(msg.Params) > 1 {
+			notice = string(msg.Params[1])
+		}
+		outcome.err = &SendError{Channel: channel, MsgID: msgID, Notice: notice}
+	default:
+		return
+	}
+
+	c.mu.Lock()
+	echoes := c.echoes[connChannel{conn, channel}]
+	var pending *pendingSend
+	switch nonce := string(msg.Tags["client-nonce"]); {
+	case nonce != "":
+		pending = c.pendingByNonce(channel, nonce)
+		if echoes != nil {
+			for i, p := range echoes.sends {
+				if p == pending {
+					echoes.sends = append(echoes.sends[:i:i], echoes.sends[i+1:]...)
+					break
+				}
+			}
+		}
+	case echoes == nil:
+	case echoes.joined && outcome.userState.Command != nil:
+		echoes.joined = false
+	case len(echoes.sends) != 0:
+		pending = echoes.sends[0]
+		echoes.sends = echoes.sends[1:]
+	}
+	if pending == nil || !c.removeSend(channel, pending) { // a PRIVMSG of Say or not ours
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	pending.result <- outcome
+}
@@ -0,0 +1,165 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendMessage(t *testing.T) {
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		if _, err := conn.readUntil("NICK"); err != nil {
+			return
+		}
+		_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+		for {
+			line, err := conn.readUntil("PRIVMSG")
+			if err != nil {
+				return
+			}
+			msg, err := parseIRCMessage([]byte(line))
+			if err != nil || len(msg.Tags["client-nonce"]) != 32 {
+				t.Errorf("line without a client-nonce: %q", line)
+				return
+			}
+			switch string(msg.Params[1]) {
+			case "hi":
+				_ = conn.writeLine("@badges=;color=;display-name=justinfan1234;mod=0 :tmi.twitch.tv USERSTATE #spddl")
+			case "again":
+				_ = conn.writeLine("@msg-id=msg_duplicate :tmi.twitch.tv NOTICE #spddl :Your message was not sent because it is identical to the previous one you sent, less than 30 seconds ago.")
+			}
+		}
+	})
+	c := newTestClient(t, tmi.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := c.SendMessage(ctx, "#SPDDL", "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Channel != "spddl" || len(result.Nonce) != 32 || string(result.UserState.Command) != "USERSTATE" {
+		t.Errorf("result = %+v", result)
+	}
+
+	_, err = c.SendMessage(ctx, "spddl", "again")
+	var sendErr *SendError
+	if !errors.As(err, &sendErr) || !errors.Is(err, ErrDuplicate) || sendErr.MsgID != "msg_duplicate" {
+		t.Errorf("SendMessage = %v", err)
+	}
+
+	short, cancelShort := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelShort()
+	if _, err := c.SendMessage(short, "spddl", "unanswered"); err != context.DeadlineExceeded {
+		t.Errorf("unanswered SendMessage = %v", err)
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.pendingSends) != 0 {
+		t.Errorf("pending sends left: %v", c.pendingSends)
+	}
+}
+
+func TestResolveSendInOrder(t *testing.T) {
+	conn := &lineTransport{}
+	c := &Client{}
+	first := &pendingSend{nonce: "a", result: make(chan sendOutcome, 1)}
+	second := &pendingSend{nonce: "b", result: make(chan sendOutcome, 1)}
+	c.pendingSends = map[string][]*pendingSend{"spddl": {first, second}}
+
+	c.joinEchoed(conn, "spddl")
+	c.sendWritten(conn, "spddl", "a")
+	c.sendWritten(conn, "spddl", "") // a PRIVMSG of Say
+	c.sendWritten(conn, "spddl", "b")
+
+	userState := mustParse(t, "@badges=;mod=0 :tmi.twitch.tv USERSTATE #spddl")
+	c.resolveSend(conn, userState)             // after the JOIN
+	c.resolveSend(&lineTransport{}, userState) // another connection
+	select {
+	case outcome := <-first.result:
+		t.Fatalf("resolved by the USERSTATE of the JOIN or of another connection: %+v", outcome)
+	default:
+	}
+
+	c.resolveSend(conn, userState)
+	if outcome := <-first.result; outcome.err != nil || string(outcome.userState.Command) != "USERSTATE" {
+		t.Errorf("first = %+v", outcome)
+	}
+	c.resolveSend(conn, userState) // answers Say
+	c.resolveSend(conn, mustParse(t, "@msg-id=msg_duplicate :tmi.twitch.tv NOTICE #spddl :Your message was not sent because it is identical to the previous one you sent, less than 30 seconds ago."))
+	if outcome := <-second.result; !errors.Is(outcome.err, ErrDuplicate) {
+		t.Errorf("second = %+v", outcome)
+	}
+}
+
+func TestResolveSendByNonce(t *testing.T) {
+	conn := &lineTransport{}
+	c := &Client{}
+	first := &pendingSend{nonce: "a", result: make(chan sendOutcome, 1)}
+	second := &pendingSend{nonce: "b", result: make(chan sendOutcome, 1)}
+	c.pendingSends = map[string][]*pendingSend{"spddl": {first, second}}
+
+	c.resolveSend(conn, mustParse(t, "@client-nonce=b :tmi.twitch.tv USERSTATE #spddl"))
+	c.resolveSend(conn, mustParse(t, "@client-nonce=x :tmi.twitch.tv USERSTATE #spddl")) // not ours
+	c.resolveSend(conn, mustParse(t, "@msg-id=msg_channel_suspended :tmi.twitch.tv NOTICE #spddl :This channel has been suspended."))
+	select {
+	case <-second.result:
+	default:
+		t.Fatal("the echo with nonce b did not resolve the second message")
+	}
+	select {
+	case outcome := <-first.result:
+		t.Fatalf("the first message was resolved: %+v", outcome)
+	default:
+	}
+
+	slowMode := mustParse(t, "@msg-id=msg_slowmode :tmi.twitch.tv NOTICE #spddl :This room is in slow mode and you are sending messages too quickly.")
+	c.resolveSend(conn, slowMode) // before the PRIVMSG was written
+	select {
+	case outcome := <-first.result:
+		t.Fatalf("a NOTICE before the PRIVMSG resolved the message: %+v", outcome)
+	default:
+	}
+
+	c.sendWritten(conn, "spddl", "a")
+	c.resolveSend(conn, slowMode)
+	outcome := <-first.result
+	if !errors.Is(outcome.err, ErrSlowMode) || !strings.Contains(outcome.err.Error(), "#spddl") {
+		t.Errorf("err = %v", outcome.err)
+	}
+}
+
+func TestSendMessageDropped(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	c := newQueueClient(QueueDropNewest)
+	c.emitQueue.RateLimit <- "x"
+	c.emitQueue.RateLimit <- "y"
+	if _, err := c.SendMessage(ctx, "spddl", "dropped"); err != ErrQueueFull {
+		t.Errorf("SendMessage to a full queue with QueueDropNewest = %v", err)
+	}
+
+	c = newQueueClient(QueueDropOldest)
+	done := make(chan error)
+	go func() {
+		_, err := c.SendMessage(ctx, "spddl", "evicted")
+		done <- err
+	}()
+	for len(c.emitQueue.RateLimit) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Say("spddl", "1", false)
+	c.Say("spddl", "2", false)
+	if err := <-done; err != ErrQueueFull {
+		t.Errorf("SendMessage evicted by QueueDropOldest = %v", err)
+	}
+}
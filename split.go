@@ -0,0 +1,109 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxMessageLength of a PRIVMSG in code points, tmi drops longer messages
+const MaxMessageLength = 500
+
+// SplitError is returned by Say and TrySay if only the first Sent pieces of a split message were queued
+type SplitError struct {
+	Sent   int
+	Pieces int
+	Err    error
+}
+
+func (e *SplitError) Error() string {
+	return fmt.Sprintf("twitch: %d of %d pieces sent: %v", e.Sent, e.Pieces, e.Err)
+}
+
+func (e *SplitError) Unwrap() error {
+	return e.Err
+}
+
+// SplitMessage breaks text into pieces of at most max code points. It breaks at spaces before
+// the word, emote or URL that doesn't fit, so they stay whole, and only splits a token that is
+// longer than a piece on its own, then between graphemes. Every piece but the last ends with marker, e.g. " …".
+func SplitMessage(text string, max int, marker string) []string {
+	runes := []rune(text)
+	if len(runes) <= max || max <= 0 {
+		return []string{text}
+	}
+
+	limit := max - utf8.RuneCountInString(marker)
+	if limit < 1 { // no room for the marker
+		limit, marker = max, ""
+	}
+
+	var pieces []string
+	for len(runes) > max {
+		cut := splitAt(runes, limit)
+		piece := strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace)
+		if piece != "" {
+			pieces = append(pieces, piece+marker)
+		}
+
+		runes = runes[cut:]
+		for len(runes) != 0 && unicode.IsSpace(runes[0]) {
+			runes = runes[1:]
+		}
+	}
+	if len(runes) != 0 {
+		pieces = append(pieces, string(runes))
+	}
+	return pieces
+}
+
+// splitAt returns where a piece of at most limit runes ends: at the space before the token
+// that crosses limit, else (the token alone is longer than limit) at the last grapheme boundary
+func splitAt(runes []rune, limit int) int {
+	start := limit // runes[limit] is the first rune that doesn't fit
+	for start > 0 && !unicode.IsSpace(runes[start]) {
+		start--
+	}
+	if start > 0 {
+		return start
+	}
+
+	for i := limit; i > 0; i-- {
+		if graphemeBoundary(runes, i) {
+			return i
+		}
+	}
+	return limit
+}
+
+// graphemeBoundary is false between the runes of a grapheme: before combining marks,
+// variation selectors and skin tones, around zero width joiners and inside flags
+func graphemeBoundary(runes []rune, i int) bool {
+	r, prev := runes[i], runes[i-1]
+	switch {
+	case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), unicode.Is(unicode.Mc, r):
+		return false
+	case r == 0x200D || prev == 0x200D: // zero width joiner
+		return false
+	case r >= 0xFE00 && r <= 0xFE0F: // variation selectors
+		return false
+	case r >= 0x1F3FB && r <= 0x1F3FF: // skin tones
+		return false
+	case r >= 0xE0020 && r <= 0xE007F: // tag sequences of subdivision flags
+		return false
+	case isRegionalIndicator(r) && isRegionalIndicator(prev):
+		n := 0 // flags are pairs of regional indicators
+		for j := i - 1; j >= 0 && isRegionalIndicator(runes[j]); j-- {
+			n++
+		}
+		return n%2 == 0
+	}
+	return true
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
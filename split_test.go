@@ -0,0 +1,112 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitMessage(t *testing.T) {
+	for _, tt := range []struct {
+		text   string
+		max    int
+		marker string
+		want   []string
+	}{
+		{"short", 10, "…", []string{"short"}},
+		{"one two three four", 9, "", []string{"one two", "three", "four"}},
+		{"one two three four", 9, " …", []string{"one two …", "three …", "four"}},
+		{"Kappa https://example.com/a Kappa", 24, "", []string{"Kappa", "https://example.com/a", "Kappa"}},
+		{"see https://example.com/abcdefghijklmnop", 30, "", []string{"see", "https://example.com/abcdefghij", "klmnop"}}, // a URL longer than a piece
+		{"abcdefghij", 4, "", []string{"abcd", "efgh", "ij"}},                                                             // a word longer than a piece
+		{"ab👍🏽cd", 3, "", []string{"ab", "👍🏽c", "d"}},                                                                     // the skin tone stays with the thumb
+		{"ééé", 3, "", []string{"é", "é", "é"}},                                                                     // combining accents
+		{"🇩🇪🇫🇷", 3, "", []string{"🇩🇪", "🇫🇷"}},                                                                             // flags are pairs
+		{"äöü äöü", 3, "", []string{"äöü", "äöü"}},                                                                        // code points, not bytes
+	} {
+		got := SplitMessage(tt.text, tt.max, tt.marker)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitMessage(%q, %d, %q) = %q, want %q", tt.text, tt.max, tt.marker, got, tt.want)
+		}
+		for _, piece := range got {
+			if n := utf8.RuneCountInString(piece); n > tt.max {
+				t.Errorf("%q has %d code points", piece, n)
+			}
+		}
+	}
+}
+
+func TestSaySplitsLongMessages(t *testing.T) {
+	c := newQueueClient(QueueBlock)
+	c.QueueSize = 10
+	c.newQueues()
+	c.SplitLongMessages = true
+	c.ContinuationMarker = " …"
+
+	text := strings.Repeat("Kappa ", 200) // 1200 code points
+	if err := c.Say("spddl", text, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var pieces []string
+	for _, line := range drain(c.emitQueue.RateLimit) {
+		pieces = append(pieces, strings.TrimPrefix(line, ":tmi.twitch.tv PRIVMSG #spddl :"))
+	}
+	if len(pieces) != 3 {
+		t.Fatalf("%d pieces", len(pieces))
+	}
+	joined := strings.Replace(strings.Join(pieces, " "), " …", "", -1)
+	if strings.TrimSpace(joined) != strings.TrimSpace(text) {
+		t.Errorf("the pieces are not the message in order: %q", pieces)
+	}
+	for i, piece := range pieces {
+		if n := utf8.RuneCountInString(piece); n > MaxMessageLength || (i < 2) != strings.HasSuffix(piece, " …") {
+			t.Errorf("piece %d: %d code points, %q", i, n, piece[len(piece)-10:])
+		}
+	}
+}
+
+func TestSaySplitChecksEveryPiece(t *testing.T) {
+	c := newQueueClient(QueueBlock)
+	c.QueueSize = 10
+	c.newQueues()
+	c.SplitLongMessages = true
+	c.DuplicateStrategy = DuplicateDrop
+
+	text := strings.Repeat("Kappa ", 200) // the first two of three pieces are identical
+	if err := c.Say("spddl", text, false); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("Say = %v, want ErrDuplicate", err)
+	}
+	if depth := len(c.emitQueue.RateLimit); depth != 0 {
+		t.Errorf("%d pieces queued before the duplicate was found", depth)
+	}
+
+	c.DuplicateStrategy = DuplicateSend
+	for i := 0; i < 9; i++ { // room for one piece
+		c.emitQueue.RateLimit <- "x"
+	}
+	var splitErr *SplitError
+	if err := c.TrySay("spddl", text, false); !errors.As(err, &splitErr) || splitErr.Sent != 1 || splitErr.Pieces != 3 || !errors.Is(err, ErrQueueFull) {
+		t.Errorf("TrySay = %v, want 1 of 3 pieces sent", err)
+	}
+}
+
+func TestSaySplitsLongURL(t *testing.T) {
+	c := newQueueClient(QueueBlock)
+	c.QueueSize = 10
+	c.newQueues()
+	c.SplitLongMessages = true
+
+	url := "https://example.com/" + strings.Repeat("a", 450)
+	if err := c.Say("spddl", strings.Repeat("Kappa ", 10)+url, false); err != nil {
+		t.Fatal(err)
+	}
+	pieces := drain(c.emitQueue.RateLimit)
+	if len(pieces) != 2 || !strings.HasSuffix(pieces[1], " :"+url) {
+		t.Errorf("the URL was cut: %q", pieces)
+	}
+}
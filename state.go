@@ -0,0 +1,86 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"errors"
+)
+
+// State of the connection to tmi
+type State int
+
+const (
+	Disconnected   State = iota // before Run/Connect, after a failed login or when the ReconnectPolicy gave up
+	Dialing                     // opening the websocket
+	Authenticating              // CAP/PASS/NICK sent, waiting for RPL_WELCOME
+	Ready                       // welcomed, messages are sent
+	Reconnecting                // waiting for the next dial attempt
+	Closing
+	Closed
+)
+
+var stateNames = [...]string{"Disconnected", "Dialing", "Authenticating", "Ready", "Reconnecting", "Closing", "Closed"}
+
+func (s State) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return "State(?)"
+	}
+	return stateNames[s]
+}
+
+// ErrClosed is returned by WaitReady after Close
+var ErrClosed = errors.New("twitch: client closed")
+
+// State returns the current connection state
+func (c *Client) State() State {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
+}
+
+// setState changes the state, a closed client stays closed
+func (c *Client) setState(state State) {
+	c.mu.Lock()
+	old := c.state
+	if old == state || ((old == Closing || old == Closed) && state != Closed) {
+		c.mu.Unlock()
+		return
+	}
+	c.state = state
+	if c.stateChanged != nil {
+		close(c.stateChanged) // wakes up WaitReady
+	}
+	c.stateChanged = make(chan struct{})
+	c.mu.Unlock()
+
+	if c.OnStateChange != nil {
+		c.OnStateChange(old, state)
+	}
+}
+
+// WaitReady blocks until the client is Ready, ctx is done or the client is closed
+func (c *Client) WaitReady(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		state := c.state
+		if c.stateChanged == nil {
+			c.stateChanged = make(chan struct{})
+		}
+		changed := c.stateChanged
+		c.mu.Unlock()
+
+		switch state {
+		case Ready:
+			return nil
+		case Closing, Closed:
+			return ErrClosed
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
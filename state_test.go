@@ -0,0 +1,95 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStateChanges(t *testing.T) {
+	tmi := newFakeTMI(t, welcome)
+	c := newTestClient(t, tmi.URL)
+
+	var mu sync.Mutex
+	var states []State
+	c.OnStateChange = func(old, new State) {
+		mu.Lock()
+		states = append(states, new)
+		mu.Unlock()
+	}
+
+	if c.State() != Disconnected {
+		t.Fatalf("initial state %v", c.State())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Close()
+	if err := c.WaitReady(ctx); err != ErrClosed {
+		t.Errorf("WaitReady after Close = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []State{Dialing, Authenticating, Ready, Closing, Closed}
+	if !reflect.DeepEqual(states, want) {
+		t.Errorf("states = %v, want %v", states, want)
+	}
+}
+
+func TestWaitReadyTimeout(t *testing.T) {
+	c := newTestClient(t, "ws://127.0.0.1:0")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.WaitReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitReady = %v", err)
+	}
+}
+
+func TestSendWaitsForReady(t *testing.T) {
+	received := make(chan string, 1)
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		if _, err := conn.readUntil("NICK"); err != nil {
+			return
+		}
+		time.Sleep(100 * time.Millisecond) // the PRIVMSG must not overtake the welcome
+		_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+		line, err := conn.readUntil("PRIVMSG")
+		if err == nil {
+			received <- line
+		}
+		_, _ = conn.readLine()
+	})
+	c := newTestClient(t, tmi.URL)
+
+	go c.Run()
+	go c.Say("spddl", "hi", false)
+
+	select {
+	case line := <-received:
+		if line != ":tmi.twitch.tv PRIVMSG #spddl :hi" {
+			t.Errorf("line = %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("PRIVMSG was not sent")
+	}
+}
+
+func TestStateString(t *testing.T) {
+	if Ready.String() != "Ready" || State(42).String() != "State(?)" {
+		t.Error(Ready.String(), State(42).String())
+	}
+}
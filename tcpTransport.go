@@ -0,0 +1,88 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/url"
+	"sync"
+)
+
+// TCPTransport speaks plain IRC, irc://irc.chat.twitch.tv:6667 or with TLS ircs://irc.chat.twitch.tv:6697
+type TCPTransport struct {
+	TLS bool
+
+	conn   net.Conn
+	reader *bufio.Reader
+	mu     sync.Mutex // one line per Write
+}
+
+func (t *TCPTransport) Dial(ctx context.Context, server string, opts *DialOptions) error {
+	addr := server
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		addr = u.Host
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil { // no port
+		if t.TLS {
+			addr = net.JoinHostPort(addr, "6697")
+		} else {
+			addr = net.JoinHostPort(addr, "6667")
+		}
+	}
+
+	var conn net.Conn
+	var err error
+	if t.TLS {
+		dialer := &tls.Dialer{}
+		if opts != nil {
+			dialer.Config = opts.TLSConfig
+		}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	t.conn = conn
+	t.reader = bufio.NewReader(conn)
+	return nil
+}
+
+// ReadFrame returns one line, a line ending with "\n" only (netcat) is accepted as well
+func (t *TCPTransport) ReadFrame(ctx context.Context) ([]byte, error) {
+	if t.conn == nil {
+		return nil, errNotDialed
+	}
+	line, err := t.reader.ReadBytes(10) // "\n"
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+func (t *TCPTransport) WriteLine(ctx context.Context, line []byte) error {
+	if t.conn == nil {
+		return errNotDialed
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	deadline, _ := ctx.Deadline() // zero without a deadline
+	_ = t.conn.SetWriteDeadline(deadline)
+	frame := make([]byte, 0, len(line)+2)
+	frame = append(append(frame, line...), 13, 10) // "\r\n"
+	_, err := t.conn.Write(frame)
+	return err
+}
+
+func (t *TCPTransport) Close() error {
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.Close()
+}
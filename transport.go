@@ -0,0 +1,78 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"nhooyr.io/websocket"
+)
+
+// Transport is one connection to tmi, a new one is dialed for every (re)connect
+type Transport interface {
+	Dial(ctx context.Context, server string, opts *DialOptions) error
+	// ReadFrame blocks until the next frame, it holds one or more lines separated by "\r\n"
+	ReadFrame(ctx context.Context) ([]byte, error)
+	// WriteLine sends one line, the transport appends "\r\n"
+	WriteLine(ctx context.Context, line []byte) error
+	Close() error
+}
+
+var errNotDialed = errors.New("twitch: transport is not dialed")
+
+// newTransport returns Client.NewTransport or picks one by the scheme of Server:
+// irc:// and ircs:// are plain IRC over TCP and TLS, everything else is a websocket
+func (c *Client) newTransport() Transport {
+	if c.NewTransport != nil {
+		return c.NewTransport(c.Server)
+	}
+
+	switch {
+	case strings.HasPrefix(c.Server, "irc://"):
+		return &TCPTransport{}
+	case strings.HasPrefix(c.Server, "ircs://"):
+		return &TCPTransport{TLS: true}
+	default:
+		return &WebsocketTransport{}
+	}
+}
+
+// WebsocketTransport speaks to ws:// or wss://irc-ws.chat.twitch.tv
+type WebsocketTransport struct {
+	conn *websocket.Conn
+}
+
+func (t *WebsocketTransport) Dial(ctx context.Context, server string, opts *DialOptions) error {
+	conn, _, err := websocket.Dial(ctx, server, opts.websocket())
+	if err != nil {
+		return err
+	}
+	t.conn = conn
+	return nil
+}
+
+func (t *WebsocketTransport) ReadFrame(ctx context.Context) ([]byte, error) {
+	if t.conn == nil {
+		return nil, errNotDialed
+	}
+	_, data, err := t.conn.Read(ctx)
+	return data, err
+}
+
+func (t *WebsocketTransport) WriteLine(ctx context.Context, line []byte) error {
+	if t.conn == nil {
+		return errNotDialed
+	}
+	frame := make([]byte, 0, len(line)+2)
+	frame = append(append(frame, line...), 13, 10) // "\r\n"
+	return t.conn.Write(ctx, websocket.MessageText, frame)
+}
+
+func (t *WebsocketTransport) Close() error {
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.Close(websocket.StatusNormalClosure, "")
+}
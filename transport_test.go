@@ -0,0 +1,85 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTransport(t *testing.T) {
+	for server, want := range map[string]Transport{
+		"wss://irc-ws.chat.twitch.tv":       &WebsocketTransport{},
+		"ws://irc-ws.chat.twitch.tv":        &WebsocketTransport{},
+		"irc://irc.chat.twitch.tv:6667":     &TCPTransport{},
+		"ircs://irc.chat.twitch.tv:6697":    &TCPTransport{TLS: true},
+		"ircs://irc.chat.twitch.tv/ignored": &TCPTransport{TLS: true},
+	} {
+		c := &Client{Server: server}
+		got := c.newTransport()
+		switch want := want.(type) {
+		case *TCPTransport:
+			if tcp, ok := got.(*TCPTransport); !ok || tcp.TLS != want.TLS {
+				t.Errorf("%s: %T %+v", server, got, got)
+			}
+		case *WebsocketTransport:
+			if _, ok := got.(*WebsocketTransport); !ok {
+				t.Errorf("%s: %T", server, got)
+			}
+		}
+	}
+}
+
+func TestTCPTransport(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "NICK"):
+				conn.Write([]byte(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!\n")) // netcat style
+			case strings.Contains(line, "PRIVMSG"):
+				received <- line
+			}
+		}
+	}()
+
+	c := newTestClient(t, "irc://"+ln.Addr().String())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.getConn().(*TCPTransport); !ok {
+		t.Fatalf("transport %T", c.getConn())
+	}
+
+	go c.Say("spddl", "hi", false)
+	select {
+	case line := <-received:
+		if line != ":tmi.twitch.tv PRIVMSG #spddl :hi\r\n" {
+			t.Errorf("line = %q", line)
+		}
+	case <-ctx.Done():
+		t.Fatal("PRIVMSG was not sent")
+	}
+}
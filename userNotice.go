@@ -0,0 +1,244 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UserNotice holds the tags every USERNOTICE has https://dev.twitch.tv/docs/irc/tags#usernotice-twitch-tags
+// it is also the event for msg-ids without a typed event
+type UserNotice struct {
+	Channel     string // without #
+	MsgID       string
+	UserLogin   string
+	UserID      string
+	DisplayName string
+	SystemMsg   string
+	Text        string // optional message of the user
+	ID          string
+	RoomID      string
+	Time        time.Time
+	Badges      Badges
+	BadgeInfo   Badges
+	Color       string
+	Emotes      []Emote
+
+	IRCMessage IRCMessage
+}
+
+// SubEvent msg-id=sub
+type SubEvent struct {
+	UserNotice
+	CumulativeMonths   int
+	StreakMonths       int
+	ShouldShareStreak  bool
+	SubPlan            string // Prime, 1000, 2000 or 3000
+	SubPlanName        string
+	MultimonthDuration int
+	MultimonthTenure   int
+	WasGifted          bool
+}
+
+// ResubEvent msg-id=resub
+type ResubEvent SubEvent
+
+// SubGiftEvent msg-id=subgift and msg-id=anonsubgift
+type SubGiftEvent struct {
+	UserNotice
+	Anonymous            bool
+	Months               int
+	GiftMonths           int
+	RecipientLogin       string
+	RecipientID          string
+	RecipientDisplayName string
+	SubPlan              string
+	SubPlanName          string
+	SenderCount          int // total gifts of the sender in the channel, 0 if not shared
+}
+
+// MysteryGiftEvent msg-id=submysterygift and msg-id=anonsubmysterygift
+type MysteryGiftEvent struct {
+	UserNotice
+	Anonymous     bool
+	MassGiftCount int
+	SubPlan       string
+	SenderCount   int
+}
+
+// RaidEvent msg-id=raid
+type RaidEvent struct {
+	UserNotice
+	RaiderLogin       string
+	RaiderDisplayName string
+	ViewerCount       int
+}
+
+// RitualEvent msg-id=ritual
+type RitualEvent struct {
+	UserNotice
+	RitualName string
+}
+
+// BitsBadgeTierEvent msg-id=bitsbadgetier
+type BitsBadgeTierEvent struct {
+	UserNotice
+	Threshold int
+}
+
+// AnnouncementEvent msg-id=announcement
+type AnnouncementEvent struct {
+	UserNotice
+	AnnouncementColor string // PRIMARY, BLUE, GREEN, ORANGE or PURPLE
+}
+
+// ParseUserNotice converts a USERNOTICE IRCMessage into one of the typed events,
+// unknown msg-ids are returned as UserNotice
+func ParseUserNotice(msg IRCMessage) interface{} {
+	un := UserNotice{
+		MsgID:       string(msg.Tags["msg-id"]),
+		UserLogin:   string(msg.Tags["login"]),
+		UserID:      string(msg.Tags["user-id"]),
+		DisplayName: string(msg.Tags["display-name"]),
+		SystemMsg:   string(msg.Tags["system-msg"]),
+		ID:          string(msg.Tags["id"]),
+		RoomID:      string(msg.Tags["room-id"]),
+		Time:        parseTmiSentTs(msg.Tags["tmi-sent-ts"]),
+		Badges:      msg.Badges(),
+		BadgeInfo:   msg.BadgeInfo(),
+		Color:       string(msg.Tags["color"]),
+		IRCMessage:  msg,
+	}
+	if len(msg.Params) > 0 {
+		un.Channel = strings.TrimPrefix(string(msg.Params[0]), "#")
+	}
+	if len(msg.Params) > 1 {
+		un.Text = string(msg.Params[1])
+	}
+	un.Emotes = parseEmotesTag(msg.Tags["emotes"], un.Text)
+
+	switch un.MsgID {
+	case "sub", "resub":
+		event := SubEvent{
+			UserNotice:         un,
+			CumulativeMonths:   tagInt(msg, "msg-param-cumulative-months"),
+			StreakMonths:       tagInt(msg, "msg-param-streak-months"),
+			ShouldShareStreak:  tagBool(msg, "msg-param-should-share-streak"),
+			SubPlan:            string(msg.Tags["msg-param-sub-plan"]),
+			SubPlanName:        string(msg.Tags["msg-param-sub-plan-name"]),
+			MultimonthDuration: tagInt(msg, "msg-param-multimonth-duration"),
+			MultimonthTenure:   tagInt(msg, "msg-param-multimonth-tenure"),
+			WasGifted:          tagBool(msg, "msg-param-was-gifted"),
+		}
+		if un.MsgID == "resub" {
+			return ResubEvent(event)
+		}
+		return event
+
+	case "subgift", "anonsubgift":
+		return SubGiftEvent{
+			UserNotice:           un,
+			Anonymous:            un.MsgID == "anonsubgift" || un.UserLogin == "ananonymousgifter",
+			Months:               tagInt(msg, "msg-param-months"),
+			GiftMonths:           tagInt(msg, "msg-param-gift-months"),
+			RecipientLogin:       string(msg.Tags["msg-param-recipient-user-name"]),
+			RecipientID:          string(msg.Tags["msg-param-recipient-id"]),
+			RecipientDisplayName: string(msg.Tags["msg-param-recipient-display-name"]),
+			SubPlan:              string(msg.Tags["msg-param-sub-plan"]),
+			SubPlanName:          string(msg.Tags["msg-param-sub-plan-name"]),
+			SenderCount:          tagInt(msg, "msg-param-sender-count"),
+		}
+
+	case "submysterygift", "anonsubmysterygift":
+		return MysteryGiftEvent{
+			UserNotice:    un,
+			Anonymous:     un.MsgID == "anonsubmysterygift" || un.UserLogin == "ananonymousgifter",
+			MassGiftCount: tagInt(msg, "msg-param-mass-gift-count"),
+			SubPlan:       string(msg.Tags["msg-param-sub-plan"]),
+			SenderCount:   tagInt(msg, "msg-param-sender-count"),
+		}
+
+	case "raid":
+		return RaidEvent{
+			UserNotice:        un,
+			RaiderLogin:       string(msg.Tags["msg-param-login"]),
+			RaiderDisplayName: string(msg.Tags["msg-param-displayName"]),
+			ViewerCount:       tagInt(msg, "msg-param-viewerCount"),
+		}
+
+	case "ritual":
+		return RitualEvent{
+			UserNotice: un,
+			RitualName: string(msg.Tags["msg-param-ritual-name"]),
+		}
+
+	case "bitsbadgetier":
+		return BitsBadgeTierEvent{
+			UserNotice: un,
+			Threshold:  tagInt(msg, "msg-param-threshold"),
+		}
+
+	case "announcement":
+		return AnnouncementEvent{
+			UserNotice:        un,
+			AnnouncementColor: string(msg.Tags["msg-param-color"]),
+		}
+	}
+
+	return un
+}
+
+func (c *Client) userNoticeEvent(msg IRCMessage) {
+	switch event := ParseUserNotice(msg).(type) {
+	case SubEvent:
+		if c.OnSubEvent != nil {
+			c.OnSubEvent(event)
+		}
+	case ResubEvent:
+		if c.OnResubEvent != nil {
+			c.OnResubEvent(event)
+		}
+	case SubGiftEvent:
+		if c.OnSubGiftEvent != nil {
+			c.OnSubGiftEvent(event)
+		}
+	case MysteryGiftEvent:
+		if c.OnMysteryGiftEvent != nil {
+			c.OnMysteryGiftEvent(event)
+		}
+	case RaidEvent:
+		if c.OnRaidEvent != nil {
+			c.OnRaidEvent(event)
+		}
+	case RitualEvent:
+		if c.OnRitualEvent != nil {
+			c.OnRitualEvent(event)
+		}
+	case BitsBadgeTierEvent:
+		if c.OnBitsBadgeTierEvent != nil {
+			c.OnBitsBadgeTierEvent(event)
+		}
+	case AnnouncementEvent:
+		if c.OnAnnouncementEvent != nil {
+			c.OnAnnouncementEvent(event)
+		}
+	case UserNotice:
+		if c.OnUnknownUserNotice != nil {
+			c.OnUnknownUserNotice(event)
+		}
+	}
+}
+
+// tagInt returns 0 for missing or non numeric tags
+func tagInt(msg IRCMessage, key string) int {
+	i, _ := strconv.Atoi(string(msg.Tags[key]))
+	return i
+}
+
+// tagBool accepts "1" and "true"
+func tagBool(msg IRCMessage, key string) bool {
+	value := string(msg.Tags[key])
+	return value == "1" || value == "true"
+}
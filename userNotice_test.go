@@ -0,0 +1,107 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"testing"
+)
+
+func TestParseUserNoticeSub(t *testing.T) {
+	event, ok := ParseUserNotice(mustParse(t, `@badge-info=;badges=premium/1;color=;display-name=scharcko;emotes=;flags=;id=a230bcbb-174d-4c1b-8b96-ff1b5071f43e;login=scharcko;mod=0;msg-id=sub;msg-param-cumulative-months=1;msg-param-months=0;msg-param-multimonth-duration=0;msg-param-multimonth-tenure=0;msg-param-should-share-streak=0;msg-param-sub-plan-name=Channel\sSubscription\s(gronkhtv);msg-param-sub-plan=Prime;msg-param-was-gifted=false;room-id=106159308;subscriber=1;system-msg=scharcko\ssubscribed\swith\sTwitch\sPrime.;tmi-sent-ts=1601974819657;user-id=143102219;user-type= :tmi.twitch.tv USERNOTICE #gronkhtv`)).(SubEvent)
+	if !ok {
+		t.Fatalf("got %T, want SubEvent", event)
+	}
+	if event.Channel != "gronkhtv" || event.UserLogin != "scharcko" || event.SystemMsg != "scharcko subscribed with Twitch Prime." {
+		t.Errorf("notice = %+v", event.UserNotice)
+	}
+	if event.CumulativeMonths != 1 || event.SubPlan != "Prime" || event.SubPlanName != "Channel Subscription (gronkhtv)" || event.WasGifted || event.ShouldShareStreak {
+		t.Errorf("sub = %+v", event)
+	}
+}
+
+func TestParseUserNoticeEvents(t *testing.T) {
+	tests := []struct {
+		line  string
+		check func(event interface{}) bool
+	}{
+		{
+			`@badges=subscriber/12;display-name=Foo;login=foo;msg-id=resub;msg-param-cumulative-months=12;msg-param-streak-months=3;msg-param-should-share-streak=1;msg-param-sub-plan=1000 :tmi.twitch.tv USERNOTICE #spddl :hello`,
+			func(event interface{}) bool {
+				e, ok := event.(ResubEvent)
+				return ok && e.CumulativeMonths == 12 && e.StreakMonths == 3 && e.ShouldShareStreak && e.SubPlan == "1000" && e.Text == "hello"
+			},
+		},
+		{
+			`@login=ananonymousgifter;msg-id=subgift;msg-param-months=2;msg-param-gift-months=6;msg-param-recipient-user-name=bar;msg-param-recipient-id=42;msg-param-recipient-display-name=Bar;msg-param-sub-plan=2000 :tmi.twitch.tv USERNOTICE #spddl`,
+			func(event interface{}) bool {
+				e, ok := event.(SubGiftEvent)
+				return ok && e.Anonymous && e.Months == 2 && e.GiftMonths == 6 && e.RecipientLogin == "bar" && e.RecipientID == "42" && e.RecipientDisplayName == "Bar" && e.SubPlan == "2000"
+			},
+		},
+		{
+			`@login=foo;msg-id=submysterygift;msg-param-mass-gift-count=5;msg-param-sender-count=50;msg-param-sub-plan=1000 :tmi.twitch.tv USERNOTICE #spddl`,
+			func(event interface{}) bool {
+				e, ok := event.(MysteryGiftEvent)
+				return ok && !e.Anonymous && e.MassGiftCount == 5 && e.SenderCount == 50
+			},
+		},
+		{
+			`@login=foo;msg-id=raid;msg-param-displayName=Foo;msg-param-login=foo;msg-param-viewerCount=1337 :tmi.twitch.tv USERNOTICE #spddl`,
+			func(event interface{}) bool {
+				e, ok := event.(RaidEvent)
+				return ok && e.RaiderLogin == "foo" && e.RaiderDisplayName == "Foo" && e.ViewerCount == 1337
+			},
+		},
+		{
+			`@login=foo;msg-id=ritual;msg-param-ritual-name=new_chatter :tmi.twitch.tv USERNOTICE #spddl :HeyGuys`,
+			func(event interface{}) bool {
+				e, ok := event.(RitualEvent)
+				return ok && e.RitualName == "new_chatter"
+			},
+		},
+		{
+			`@login=foo;msg-id=bitsbadgetier;msg-param-threshold=10000 :tmi.twitch.tv USERNOTICE #spddl`,
+			func(event interface{}) bool {
+				e, ok := event.(BitsBadgeTierEvent)
+				return ok && e.Threshold == 10000
+			},
+		},
+		{
+			`@login=foo;msg-id=announcement;msg-param-color=PURPLE :tmi.twitch.tv USERNOTICE #spddl :news`,
+			func(event interface{}) bool {
+				e, ok := event.(AnnouncementEvent)
+				return ok && e.AnnouncementColor == "PURPLE" && e.Text == "news"
+			},
+		},
+		{
+			`@login=foo;msg-id=giftpaidupgrade :tmi.twitch.tv USERNOTICE #spddl`,
+			func(event interface{}) bool {
+				e, ok := event.(UserNotice)
+				return ok && e.MsgID == "giftpaidupgrade"
+			},
+		},
+	}
+	for _, tt := range tests {
+		event := ParseUserNotice(mustParse(t, tt.line))
+		if !tt.check(event) {
+			t.Errorf("%s: got %+v", tt.line, event)
+		}
+	}
+}
+
+func TestUserNoticeCallbacks(t *testing.T) {
+	var raid RaidEvent
+	var unknown int
+	c := &Client{
+		OnRaidEvent:         func(event RaidEvent) { raid = event },
+		OnUnknownUserNotice: func(event UserNotice) { unknown++ },
+	}
+	c.parser([]byte("@msg-id=raid;msg-param-login=foo;msg-param-viewerCount=3 :tmi.twitch.tv USERNOTICE #spddl\r\n@msg-id=whatever :tmi.twitch.tv USERNOTICE #spddl\r\n"))
+
+	if raid.RaiderLogin != "foo" || raid.ViewerCount != 3 {
+		t.Errorf("raid = %+v", raid)
+	}
+	if unknown != 1 {
+		t.Errorf("unknown called %d times", unknown)
+	}
+}
@@ -8,8 +8,6 @@ import (
 	"math/rand"
 	"sync"
 	"time"
-
-	"nhooyr.io/websocket"
 )
 
 type EmitQueue struct {
@@ -30,19 +28,60 @@ type Client struct {
 	BotVerified bool
 	BotKnown    bool
 	Channel     []string
+	RawTags     bool // keep the IRCv3 escapes (\s, \:, ...) in the tag values
+
+	ReconnectPolicy    ReconnectPolicy                        // nil is ExponentialBackoff from 1s up to 10min
+	NewTransport       func(server string) Transport          // nil picks the Transport by the scheme of Server
+	DialOptions        *DialOptions                           // proxy, headers, TLS and compression
+	Limiter            *AccountLimiter                        // nil is a limiter of its own with the limits of BotVerified/BotKnown
+	WhisperStore       WhisperStore                           // nil keeps the whisper recipients of the day in the Limiter
+	QueueSize          int                                    // capacity of each outbound queue, 0 is DefaultQueueSize
+	QueuePolicy        QueuePolicy                            // of Say, Whisper and Join when their queue is full
+	FollowedAt         func(channel string) (time.Time, bool) // for followers-only rooms, nil doesn't check them
+	SplitLongMessages  bool                                   // Say sends messages over MaxMessageLength in several pieces
+	ContinuationMarker string                                 // ends every piece but the last, e.g. " …"
+	DuplicateStrategy  DuplicateStrategy                      // for a message identical to the last one of the channel within 30s
 
-	conn    *websocket.Conn
+	conn    Transport
 	context context.Context
 	cancel  context.CancelFunc
 
-	isConnected bool
-	mu          sync.RWMutex
+	state           State
+	stateChanged    chan struct{} // closed on every state change
+	reconnect       bool
+	connecting      bool
+	reconnected     bool
+	authFailures    int // rejected logins since the last RPL_WELCOME
+	handingOver     bool
+	joined          map[Transport]map[string]bool // channels confirmed by connection, the current one and a handover
+	leaving         map[string]bool               // Part was called, no JOIN or ROOMSTATE confirms these
+	joinPending     map[string]bool               // JOIN lines in the join queue
+	moderator       map[string]bool               // by channel, from USERSTATE
+	badges          map[string]Badges             // by channel, from USERSTATE
+	rooms           map[string]*RoomState         // by channel, from ROOMSTATE
+	slowUntil       map[string]time.Time          // next message in a slow mode channel
+	lastMessage     map[string]sentMessage        // by channel, for DuplicateStrategy
+	outboxes        map[string]*outbox            // by channel, messages that wait for slow mode or DuplicateDelay
+	joinEcho        chan string                   // own JOINs during a handover
+	dedupe          *messageIDs                   // message ids during a handover
+	welcome         chan error                    // set while Connect waits for RPL_WELCOME
+	handoverConn    Transport                     // the new connection of a handover until it is welcomed
+	handoverWelcome chan error                    // RPL_WELCOME or the auth NOTICE of handoverConn
+	pendingSends    map[string][]*pendingSend     // SendMessage waiting for USERSTATE or NOTICE, by channel
+	echoes          map[connChannel]*sendEchoes   // written PRIVMSGs waiting for USERSTATE or NOTICE
+	mu              sync.RWMutex
+	whisperMu       sync.Mutex // Whisper checks and records the recipients
 
 	emitQueue    EmitQueue
+	queueMu      sync.RWMutex // closing the queues
+	queuesClosed bool
+	queues       map[string]chan string // emitQueue by the names of QueueStats
+	queueDropped map[string]*uint64
 	pongReceived chan bool
 
 	OnConnect               func(message bool)
 	OnPrivateMessage        func(message IRCMessage)
+	OnPrivMsg               func(message PrivateMessage)
 	OnRoomStateMessage      func(message IRCMessage)
 	OnHosttargetMessage     func(message IRCMessage)
 	OnNoticeMessage         func(message IRCMessage)
@@ -50,6 +89,15 @@ type Client struct {
 	OnPartMessage           func(message IRCMessage)
 	OnUnknownMessage        func(message IRCMessage)
 	OnUserNoticeMessage     func(message IRCMessage)
+	OnSubEvent              func(event SubEvent)
+	OnResubEvent            func(event ResubEvent)
+	OnSubGiftEvent          func(event SubGiftEvent)
+	OnMysteryGiftEvent      func(event MysteryGiftEvent)
+	OnRaidEvent             func(event RaidEvent)
+	OnRitualEvent           func(event RitualEvent)
+	OnBitsBadgeTierEvent    func(event BitsBadgeTierEvent)
+	OnAnnouncementEvent     func(event AnnouncementEvent)
+	OnUnknownUserNotice     func(event UserNotice)
 	OnClearMsgMessage       func(message IRCMessage)
 	OnClearChatMessage      func(message IRCMessage)
 	OnGlobalUserSateMessage func(message IRCMessage)
@@ -58,6 +106,10 @@ type Client struct {
 	OnEndOfNamesMessage     func(message IRCMessage)
 	OnWhisperMessage        func(message IRCMessage)
 	OnPongLatency           func(message time.Duration)
+	OnParseError            func(err *ParseError)
+	OnReconnecting          func(attempt int, delay time.Duration, err error)
+	OnReconnected           func()
+	OnStateChange           func(old, new State)
 }
 
 func NewClient(c *Client) (*Client, error) {
@@ -66,26 +118,22 @@ func NewClient(c *Client) (*Client, error) {
 	c.mu.Lock()
 	c.context = ctx
 	c.cancel = cancel
-	c.emitQueue.Authenticate = make(chan string)
-	c.emitQueue.Join = make(chan string)
-	c.emitQueue.RateLimit = make(chan string)
-	c.emitQueue.ModOp = make(chan string)
-	c.emitQueue.Whisper = make(chan string)
-	c.emitQueue.WhisperKnownBot = make(chan string)
-	c.emitQueue.WhisperVerifiedBots = make(chan string)
+	c.newQueues()
 	if c.User == "" {
 		c.User = fmt.Sprintf("justinfan%d", rand.Intn(9999-1000)+1000)
 	}
 	c.mu.Unlock()
+	c.limiter()
 
 	go c.sendAuthenticate(c.emitQueue.Authenticate)
 	go c.sendJoin(c.emitQueue.Join)
 	go c.send(c.emitQueue.RateLimit)
 	go c.sendModOp(c.emitQueue.ModOp)
 	go c.sendWhisper(c.emitQueue.Whisper)
+	go c.sendWhisperKnownBot(c.emitQueue.WhisperKnownBot)
+	go c.sendWhisperVerifiedBots(c.emitQueue.WhisperVerifiedBots)
 
 	go c.pingPong() // takes care of the ping pong
 
-	go c.read()
 	return c, nil
 }
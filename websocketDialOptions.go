@@ -0,0 +1,46 @@
+// +build windows linux
+
+package twitch
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"nhooyr.io/websocket"
+)
+
+// websocket converts the options for websocket.Dial
+func (o *DialOptions) websocket() *websocket.DialOptions {
+	if o == nil {
+		return nil
+	}
+
+	client := o.HTTPClient
+	if client == nil && (o.Proxy != nil || o.TLSConfig != nil) {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if o.Proxy != nil {
+			transport.Proxy = o.Proxy
+		}
+		if o.TLSConfig != nil {
+			transport.TLSClientConfig = o.TLSConfig.Clone()
+		}
+		// the handshake is an HTTP/1.1 upgrade
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		client = &http.Client{Transport: transport}
+	}
+
+	mode := websocket.CompressionNoContextTakeover
+	switch o.Compression {
+	case CompressionDisabled:
+		mode = websocket.CompressionDisabled
+	case CompressionContextTakeover:
+		mode = websocket.CompressionContextTakeover
+	}
+
+	return &websocket.DialOptions{
+		HTTPClient:      client,
+		HTTPHeader:      o.HTTPHeader,
+		CompressionMode: mode,
+	}
+}
@@ -0,0 +1,10 @@
+// +build js,wasm
+
+package twitch
+
+import "nhooyr.io/websocket"
+
+// websocket ignores the options, the browser dials the websocket
+func (o *DialOptions) websocket() *websocket.DialOptions {
+	return nil
+}
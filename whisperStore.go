@@ -0,0 +1,162 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrWhisperRecipients is returned by Whisper once the account whispered
+// Limits.WhisperRecipients different accounts today
+var ErrWhisperRecipients = errors.New("twitch: daily whisper recipient limit reached")
+
+// WhisperStore remembers the accounts login whispered on day (UTC, "2006-01-02").
+// Implement it with a database to keep the count across restarts and processes.
+type WhisperStore interface {
+	// Allowed is true unless max different recipients were already whispered on day,
+	// a recipient that was already whispered on day is always allowed
+	Allowed(login, day, recipient string, max int) (bool, error)
+	// AddRecipient records recipient once a whisper to it was queued
+	AddRecipient(login, day, recipient string) error
+}
+
+// MemoryWhisperStore is the default WhisperStore, it only keeps the last day
+type MemoryWhisperStore struct {
+	mu         sync.Mutex
+	day        string
+	recipients map[string]map[string]bool // login -> recipients
+}
+
+func (s *MemoryWhisperStore) Allowed(login, day, recipient string, max int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.day != day {
+		return true, nil
+	}
+	return allowedRecipient(s.recipients, login, recipient, max), nil
+}
+
+func (s *MemoryWhisperStore) AddRecipient(login, day, recipient string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.day != day || s.recipients == nil {
+		s.day = day
+		s.recipients = map[string]map[string]bool{}
+	}
+	addRecipient(s.recipients, login, recipient)
+	return nil
+}
+
+func allowedRecipient(recipients map[string]map[string]bool, login, recipient string, max int) bool {
+	whispered := recipients[strings.ToLower(login)]
+	return whispered[strings.ToLower(recipient)] || max <= 0 || len(whispered) < max
+}
+
+func addRecipient(recipients map[string]map[string]bool, login, recipient string) {
+	login, recipient = strings.ToLower(login), strings.ToLower(recipient)
+	whispered := recipients[login]
+	if whispered == nil {
+		whispered = map[string]bool{}
+		recipients[login] = whispered
+	}
+	whispered[recipient] = true
+}
+
+// FileWhisperStore keeps the recipients of the last day in a JSON file
+type FileWhisperStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileWhisperStore(path string) *FileWhisperStore {
+	return &FileWhisperStore{path: path}
+}
+
+type whisperFile struct {
+	Day        string                     `json:"day"`
+	Recipients map[string]map[string]bool `json:"recipients"`
+}
+
+func (s *FileWhisperStore) Allowed(login, day, recipient string, max int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := s.read(day)
+	if err != nil {
+		return false, err
+	}
+	return allowedRecipient(file.Recipients, login, recipient, max), nil
+}
+
+func (s *FileWhisperStore) AddRecipient(login, day, recipient string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := s.read(day)
+	if err != nil {
+		return err
+	}
+	addRecipient(file.Recipients, login, recipient)
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	// replace the file at once, a crash keeps the old recipients
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// read returns the recipients of day, an older file is empty
+func (s *FileWhisperStore) read(day string) (whisperFile, error) {
+	var file whisperFile
+	data, err := os.ReadFile(s.path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return file, err
+	default:
+		if err := json.Unmarshal(data, &file); err != nil {
+			return file, err
+		}
+	}
+	if file.Day != day || file.Recipients == nil {
+		file = whisperFile{Day: day, Recipients: map[string]map[string]bool{}}
+	}
+	return file, nil
+}
+
+// whisperRecipient checks the daily whisper recipients of the account, nick is only
+// recorded once push queued the whisper
+func (c *Client) whisperRecipient(nick string, push func() error) error {
+	l := c.limiter()
+	store := c.WhisperStore
+	if store == nil {
+		store = &l.recipients
+	}
+
+	c.whisperMu.Lock() // no other whisper between the check and the record
+	defer c.whisperMu.Unlock()
+
+	day := l.clock.Now().UTC().Format("2006-01-02")
+	ok, err := store.Allowed(c.User, day, nick, l.limits.WhisperRecipients)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWhisperRecipients
+	}
+	if err := push(); err != nil {
+		return err
+	}
+	return store.AddRecipient(c.User, day, nick)
+}
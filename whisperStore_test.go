@@ -0,0 +1,126 @@
+// +build windows linux js,wasm
+
+package twitch
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemoryWhisperStore(t *testing.T) {
+	var s MemoryWhisperStore
+	for i, tt := range []struct {
+		day, recipient string
+		ok             bool
+	}{
+		{"2021-01-01", "a", true},
+		{"2021-01-01", "b", true},
+		{"2021-01-01", "c", false}, // max 2
+		{"2021-01-01", "A", true},  // already whispered
+		{"2021-01-02", "c", true},  // a new day
+	} {
+		ok, err := s.Allowed("spddl", tt.day, tt.recipient, 2)
+		if err != nil || ok != tt.ok {
+			t.Errorf("%d: Allowed(%q, %q) = %v, %v", i, tt.day, tt.recipient, ok, err)
+		}
+		if ok {
+			_ = s.AddRecipient("spddl", tt.day, tt.recipient)
+		}
+	}
+}
+
+func TestFileWhisperStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "whispers.json")
+	if err := NewFileWhisperStore(path).AddRecipient("spddl", "2021-01-01", "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := NewFileWhisperStore(path)
+	if ok, err := restarted.Allowed("spddl", "2021-01-01", "b", 1); ok || err != nil {
+		t.Errorf("the cap did not survive the restart: %v, %v", ok, err)
+	}
+	if ok, err := restarted.Allowed("spddl", "2021-01-01", "A", 1); !ok || err != nil {
+		t.Errorf("already whispered: %v, %v", ok, err)
+	}
+	if ok, err := restarted.Allowed("other", "2021-01-01", "b", 1); !ok || err != nil {
+		t.Errorf("another login: %v, %v", ok, err)
+	}
+}
+
+func TestWhisperKnownBot(t *testing.T) {
+	received := make(chan string, 1)
+	tmi := newFakeTMI(t, func(conn *tmiConn) {
+		if _, err := conn.readUntil("NICK"); err != nil {
+			return
+		}
+		_ = conn.writeLine(":tmi.twitch.tv 001 justinfan1234 :Welcome, GLHF!")
+		if line, err := conn.readUntil("PRIVMSG"); err == nil {
+			received <- line
+		}
+		_, _ = conn.readLine()
+	})
+
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)}
+	limits := KnownBotLimits()
+	limits.WhisperRecipients = 1
+	c, err := NewClient(&Client{Server: tmi.URL, User: "justinfan1234", BotKnown: true, Limiter: NewAccountLimiterClock(limits, clock)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Whisper("spddl", "hi")
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-ctx.Done():
+		t.Fatal("Whisper blocks")
+	}
+	select {
+	case line := <-received:
+		if line != ":tmi.twitch.tv PRIVMSG #jtv :/w spddl hi" {
+			t.Errorf("line = %q", line)
+		}
+	case <-ctx.Done():
+		t.Fatal("the whisper was not sent")
+	}
+
+	if err := c.Whisper("other", "hi"); err != ErrWhisperRecipients {
+		t.Errorf("second recipient: %v", err)
+	}
+	clock.Advance(24 * time.Hour)
+	if err := c.whisperRecipient("other", func() error { return nil }); err != nil {
+		t.Errorf("next day: %v", err)
+	}
+}
+
+func TestWhisperDroppedKeepsRecipient(t *testing.T) {
+	limits := UserLimits()
+	limits.WhisperRecipients = 1
+	c := newQueueClient(QueueError)
+	c.User = "justinfan1234"
+	c.Limiter = NewAccountLimiter(limits)
+	c.emitQueue.Whisper <- "a"
+	c.emitQueue.Whisper <- "b"
+
+	if err := c.Whisper("spddl", "hi"); err != ErrQueueFull {
+		t.Fatalf("Whisper to a full queue = %v", err)
+	}
+	drain(c.emitQueue.Whisper)
+	if err := c.Whisper("other", "hi"); err != nil {
+		t.Errorf("the dropped whisper used up the recipient: %v", err)
+	}
+}